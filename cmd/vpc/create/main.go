@@ -2,19 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/netip"
 	"os"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-xray-sdk-go/instrumentation/awsv2"
+	"github.com/aws/aws-xray-sdk-go/xray"
+
+	"github.com/velhalkaran28/AWSDemo/internal/awsvpc"
+	"github.com/velhalkaran28/AWSDemo/internal/obs"
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
 )
 
 type CreateVPCRequest struct {
@@ -30,19 +36,13 @@ type Subnet struct {
 }
 
 type CreateVPCResponse struct {
-	Message   string         `json:"message"`
-	VPCId     string         `json:"vpc_id"`
-	VPCCidr   string         `json:"vpc_cidr"`
-	Subnets   []SubnetResult `json:"subnets"`
-	CreatedAt string         `json:"created_at"`
-	CreatedBy string         `json:"created_by"`
-}
-
-type SubnetResult struct {
-	SubnetId         string `json:"subnet_id"`
-	CIDRBlock        string `json:"cidr_block"`
-	AvailabilityZone string `json:"availability_zone"`
-	Name             string `json:"name"`
+	Message   string                  `json:"message"`
+	VPCId     string                  `json:"vpc_id"`
+	VPCCidr   string                  `json:"vpc_cidr"`
+	Subnets   []vpcstore.SubnetResult `json:"subnets"`
+	CreatedAt string                  `json:"created_at"`
+	CreatedBy string                  `json:"created_by"`
+	Status    string                  `json:"status"`
 }
 
 // Error response structure
@@ -51,31 +51,54 @@ type ErrorResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-var (
-	ec2Client    *ec2.Client
-	dynamoClient *dynamodb.Client
-	vpcTableName string
-)
+// Handler bundles the AWS dependencies this Lambda needs behind small
+// interfaces (awsvpc.EC2API, vpcstore.DynamoAPI) so tests can inject fakes
+// instead of calling live AWS.
+type Handler struct {
+	EC2    *awsvpc.Client
+	Store  *vpcstore.Store
+	Region string
+}
+
+var h *Handler
 
 func init() {
 	cfg, err := config.LoadDefaultConfig(context.TODO())
 	if err != nil {
 		panic(fmt.Sprintf("unable to load SDK config: %v", err))
 	}
+	awsv2.AWSV2Instrumentor(&cfg.APIOptions)
 
-	ec2Client = ec2.NewFromConfig(cfg)
-	dynamoClient = dynamodb.NewFromConfig(cfg)
-	vpcTableName = os.Getenv("VPC_TABLE_NAME")
+	h = &Handler{
+		EC2:    awsvpc.New(ec2.NewFromConfig(cfg)),
+		Store:  vpcstore.New(dynamodb.NewFromConfig(cfg), os.Getenv("VPC_TABLE_NAME")),
+		Region: cfg.Region,
+	}
 }
 
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var response events.APIGatewayProxyResponse
+	err := xray.Capture(ctx, "CreateVPCHandler", func(ctx context.Context) error {
+		var handleErr error
+		response, handleErr = h.handle(ctx, request)
+		return handleErr
+	})
+	return response, err
+}
+
+func (h *Handler) handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	requestId := request.RequestContext.RequestID
+	ctx = obs.WithLogger(ctx, obs.NewRequestLogger(requestId), requestId)
+	logger := obs.Logger(ctx)
+
 	var vpcRequest CreateVPCRequest
 	if err := json.Unmarshal([]byte(request.Body), &vpcRequest); err != nil {
-		return errorResponse(400, "Invalid JSON", err.Error())
+		return errorResponse(ctx, 400, "Invalid JSON", err.Error())
 	}
 
-	if err := validateInput(vpcRequest); err != nil {
-		return errorResponse(400, "Validation failed", err.Error())
+	vpcPrefix, err := validateInput(vpcRequest)
+	if err != nil {
+		return errorResponse(ctx, 400, "Validation failed", err.Error())
 	}
 
 	apiKey := request.Headers["x-api-key"]
@@ -87,19 +110,93 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 		apiKey = apiKey[:20]
 	}
 
-	vpcId, err := createVPC(ctx, vpcRequest)
+	idempotencyKey := request.Headers["Idempotency-Key"]
+	if idempotencyKey == "" {
+		idempotencyKey = request.Headers["idempotency-key"]
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = hashRequest(vpcRequest)
+	}
+
+	// Check idempotency before the overlap scan: a replayed request's own
+	// VPC is already in DynamoDB by the time it replays, so scanning for
+	// overlap first would reject it against itself.
+	reservation, err := h.Store.ReserveIdempotencyKey(ctx, idempotencyKey)
 	if err != nil {
-		return errorResponse(500, "Failed to create VPC", err.Error())
+		return errorResponse(ctx, 500, "Failed to check idempotency key", err.Error())
+	}
+	if reservation.Cached != nil {
+		var cached CreateVPCResponse
+		if err := json.Unmarshal(reservation.Cached, &cached); err != nil {
+			return errorResponse(ctx, 500, "Failed to parse cached idempotent response", err.Error())
+		}
+		return successResponse(ctx, 201, cached)
+	}
+	if reservation.InFlight {
+		return errorResponse(ctx, 409, "Request already in progress", fmt.Sprintf("a request with Idempotency-Key %s is already being processed", idempotencyKey))
+	}
+
+	if overlappingVpcId, err := h.checkVPCOverlap(ctx, vpcPrefix); err != nil {
+		h.markIdempotencyFailed(ctx, idempotencyKey)
+		return errorResponse(ctx, 500, "Failed to check for CIDR overlap", err.Error())
+	} else if overlappingVpcId != "" {
+		h.markIdempotencyFailed(ctx, idempotencyKey)
+		return errorResponse(ctx, 409, "CIDR overlaps an existing VPC", fmt.Sprintf("cidr_block %s overlaps existing vpc %s", vpcRequest.CIDRBlock, overlappingVpcId))
+	}
+
+	rollbackLogger := func(resource string, err error) {
+		logger.Warn("rollback failed", "resource", resource, "error", err)
+	}
+	failureMetric := func() {
+		obs.EmitCount("VPCCreateFailed", 1, map[string]string{"CreatedBy": apiKey, "Region": h.Region})
 	}
 
-	subnetResults, err := createSubnets(ctx, vpcId, vpcRequest.Subnets)
+	var vpcId string
+	err = xray.Capture(ctx, "createVPC", func(ctx context.Context) error {
+		vpcId, err = h.EC2.CreateVPC(ctx, vpcRequest.CIDRBlock, vpcRequest.VPCName)
+		return err
+	})
 	if err != nil {
-		return errorResponse(500, "Failed to create subnets", err.Error())
+		failureMetric()
+		h.markIdempotencyFailed(ctx, idempotencyKey)
+		return errorResponse(ctx, 500, "Failed to create VPC", err.Error())
 	}
 
 	createdAt := time.Now().UTC().Format(time.RFC3339Nano)
-	if err := storeVPCMetadata(ctx, vpcId, vpcRequest, subnetResults, createdAt, apiKey); err != nil {
-		return errorResponse(500, "Failed to store metadata", err.Error())
+	err = xray.Capture(ctx, "storeVPCMetadata", func(ctx context.Context) error {
+		return h.Store.PutStatus(ctx, buildRecord(vpcId, vpcRequest, nil, createdAt, apiKey, vpcstore.StatusCreating))
+	})
+	if err != nil {
+		h.EC2.Rollback(ctx, vpcId, nil, rollbackLogger)
+		failureMetric()
+		h.markIdempotencyFailed(ctx, idempotencyKey)
+		return errorResponse(ctx, 500, "Failed to record VPC status", err.Error())
+	}
+
+	subnets := make([]awsvpc.Subnet, 0, len(vpcRequest.Subnets))
+	for _, s := range vpcRequest.Subnets {
+		subnets = append(subnets, awsvpc.Subnet{CIDRBlock: s.CIDRBlock, Name: s.Name, AvailabilityZone: s.AvailabilityZone})
+	}
+
+	var subnetResults []vpcstore.SubnetResult
+	err = xray.Capture(ctx, "createSubnets", func(ctx context.Context) error {
+		subnetResults, err = h.EC2.CreateSubnets(ctx, vpcId, subnets)
+		return err
+	})
+	if err != nil {
+		h.EC2.Rollback(ctx, vpcId, subnetResults, rollbackLogger)
+		_ = h.Store.PutStatus(ctx, buildRecord(vpcId, vpcRequest, subnetResults, createdAt, apiKey, vpcstore.StatusRolledBack))
+		failureMetric()
+		h.markIdempotencyFailed(ctx, idempotencyKey)
+		return errorResponse(ctx, 500, "Failed to create subnets", err.Error())
+	}
+
+	if err := h.Store.PutStatus(ctx, buildRecord(vpcId, vpcRequest, subnetResults, createdAt, apiKey, vpcstore.StatusCreated)); err != nil {
+		h.EC2.Rollback(ctx, vpcId, subnetResults, rollbackLogger)
+		_ = h.Store.PutStatus(ctx, buildRecord(vpcId, vpcRequest, subnetResults, createdAt, apiKey, vpcstore.StatusRolledBack))
+		failureMetric()
+		h.markIdempotencyFailed(ctx, idempotencyKey)
+		return errorResponse(ctx, 500, "Failed to store metadata", err.Error())
 	}
 
 	response := CreateVPCResponse{
@@ -109,166 +206,127 @@ func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events
 		Subnets:   subnetResults,
 		CreatedAt: createdAt,
 		CreatedBy: apiKey,
+		Status:    vpcstore.StatusCreated,
+	}
+
+	if err := h.Store.CompleteIdempotencyKey(ctx, idempotencyKey, response); err != nil {
+		// The VPC itself was created successfully; a failure to record the
+		// idempotency result leaves the key pending, so a retry with the same
+		// key gets a 409 instead of the cached response. We still return
+		// success to the caller.
+		logger.Warn("failed to complete idempotency record", "idempotency_key", idempotencyKey, "error", err)
 	}
 
-	return successResponse(201, response)
+	dimensions := map[string]string{"CreatedBy": apiKey, "Region": h.Region}
+	obs.EmitCount("VPCCreated", 1, dimensions)
+	obs.EmitCount("SubnetCreated", float64(len(subnetResults)), dimensions)
+
+	return successResponse(ctx, 201, response)
+}
+
+func buildRecord(vpcId string, req CreateVPCRequest, subnets []vpcstore.SubnetResult, createdAt, createdBy, status string) vpcstore.VPCResource {
+	return vpcstore.VPCResource{
+		VPCId:     vpcId,
+		CreatedAt: createdAt,
+		CreatedBy: createdBy,
+		VPCCidr:   req.CIDRBlock,
+		VPCName:   req.VPCName,
+		Status:    status,
+		Subnets:   subnets,
+	}
+}
+
+// hashRequest derives a stable idempotency key from the request body when
+// the caller does not supply an Idempotency-Key header.
+func hashRequest(req CreateVPCRequest) string {
+	body, _ := json.Marshal(req)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
 }
 
-func validateInput(req CreateVPCRequest) error {
+func (h *Handler) markIdempotencyFailed(ctx context.Context, key string) {
+	if err := h.Store.MarkIdempotencyFailed(ctx, key); err != nil {
+		obs.Logger(ctx).Warn("failed to mark idempotency key as failed", "idempotency_key", key, "error", err)
+	}
+}
+
+func validateInput(req CreateVPCRequest) (netip.Prefix, error) {
 	if req.CIDRBlock == "" {
-		return fmt.Errorf("cidr_block is required")
+		return netip.Prefix{}, fmt.Errorf("cidr_block is required")
 	}
 	if req.VPCName == "" {
-		return fmt.Errorf("vpc_name is required")
+		return netip.Prefix{}, fmt.Errorf("vpc_name is required")
 	}
 	if len(req.Subnets) == 0 {
-		return fmt.Errorf("at least one subnet is required")
+		return netip.Prefix{}, fmt.Errorf("at least one subnet is required")
 	}
+
+	vpcPrefix, err := awsvpc.ValidateVPCCIDR(req.CIDRBlock)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	subnets := make([]awsvpc.Subnet, 0, len(req.Subnets))
 	for i, subnet := range req.Subnets {
 		if subnet.CIDRBlock == "" {
-			return fmt.Errorf("subnet[%d]: cidr_block is required", i)
+			return netip.Prefix{}, fmt.Errorf("subnet[%d]: cidr_block is required", i)
 		}
 		if subnet.Name == "" {
-			return fmt.Errorf("subnet[%d]: name is required", i)
+			return netip.Prefix{}, fmt.Errorf("subnet[%d]: name is required", i)
 		}
+		subnets = append(subnets, awsvpc.Subnet{CIDRBlock: subnet.CIDRBlock, Name: subnet.Name, AvailabilityZone: subnet.AvailabilityZone})
 	}
-	return nil
-}
 
-func createVPC(ctx context.Context, req CreateVPCRequest) (string, error) {
-	createVPCInput := &ec2.CreateVpcInput{
-		CidrBlock: aws.String(req.CIDRBlock),
-		TagSpecifications: []ec2types.TagSpecification{
-			{
-				ResourceType: ec2types.ResourceTypeVpc,
-				Tags: []ec2types.Tag{
-					{Key: aws.String("Name"), Value: aws.String(req.VPCName)},
-					{Key: aws.String("ManagedBy"), Value: aws.String("VPC-Management-API")},
-				},
-			},
-		},
-	}
-
-	createVPCOutput, err := ec2Client.CreateVpc(ctx, createVPCInput)
-	if err != nil {
-		return "", fmt.Errorf("failed to create VPC: %v", err)
+	if err := awsvpc.ValidateSubnets(vpcPrefix, subnets); err != nil {
+		return netip.Prefix{}, err
 	}
 
-	vpcId := aws.ToString(createVPCOutput.Vpc.VpcId)
-
-	return vpcId, nil
+	return vpcPrefix, nil
 }
 
-func createSubnets(ctx context.Context, vpcId string, subnets []Subnet) ([]SubnetResult, error) {
-	results := make([]SubnetResult, 0, len(subnets))
-
-	azOutput, err := ec2Client.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
-		Filters: []ec2types.Filter{
-			{
-				Name:   aws.String("state"),
-				Values: []string{"available"},
-			},
-		},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to describe availability zones: %w", err)
-	}
-
-	availableAZs := azOutput.AvailabilityZones
-
-	for i, subnet := range subnets {
-
-		var az string
-		if subnet.AvailabilityZone != "" {
-			az = subnet.AvailabilityZone
-		} else if i < len(availableAZs) {
-			az = aws.ToString(availableAZs[i].ZoneName)
-		} else {
-			az = aws.ToString(availableAZs[0].ZoneName)
-		}
-
-		createSubnetInput := &ec2.CreateSubnetInput{
-			VpcId:            aws.String(vpcId),
-			CidrBlock:        aws.String(subnet.CIDRBlock),
-			AvailabilityZone: aws.String(az),
-			TagSpecifications: []ec2types.TagSpecification{
-				{
-					ResourceType: ec2types.ResourceTypeSubnet,
-					Tags: []ec2types.Tag{
-						{Key: aws.String("Name"), Value: aws.String(subnet.Name)},
-						{Key: aws.String("ManagedBy"), Value: aws.String("VPC-Management-API")},
-					},
-				},
-			},
-		}
-
-		createSubnetOutput, err := ec2Client.CreateSubnet(ctx, createSubnetInput)
+// checkVPCOverlap rejects the request if it overlaps a VPC already tracked
+// in DynamoDB, returning the conflicting VPC's ID.
+func (h *Handler) checkVPCOverlap(ctx context.Context, vpcPrefix netip.Prefix) (string, error) {
+	return h.Store.CheckOverlap(ctx, func(existingCidr string) bool {
+		existingPrefix, err := netip.ParsePrefix(existingCidr)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create subnet %s: %w", subnet.Name, err)
+			return false
 		}
-
-		results = append(results, SubnetResult{
-			SubnetId:         aws.ToString(createSubnetOutput.Subnet.SubnetId),
-			CIDRBlock:        subnet.CIDRBlock,
-			AvailabilityZone: az,
-			Name:             subnet.Name,
-		})
-	}
-
-	return results, nil
+		return vpcPrefix.Overlaps(existingPrefix)
+	})
 }
 
-func storeVPCMetadata(ctx context.Context, vpcId string, req CreateVPCRequest, subnets []SubnetResult, createdAt, createdBy string) error {
-	subnetItems := make([]types.AttributeValue, 0, len(subnets))
-	for _, subnet := range subnets {
-		subnetItems = append(subnetItems, &types.AttributeValueMemberM{
-			Value: map[string]types.AttributeValue{
-				"subnet_id":         &types.AttributeValueMemberS{Value: subnet.SubnetId},
-				"cidr_block":        &types.AttributeValueMemberS{Value: subnet.CIDRBlock},
-				"availability_zone": &types.AttributeValueMemberS{Value: subnet.AvailabilityZone},
-				"name":              &types.AttributeValueMemberS{Value: subnet.Name},
-			},
-		})
-	}
-
-	item := map[string]types.AttributeValue{
-		"vpc_id":     &types.AttributeValueMemberS{Value: vpcId},
-		"created_at": &types.AttributeValueMemberS{Value: createdAt},
-		"created_by": &types.AttributeValueMemberS{Value: createdBy},
-		"vpc_cidr":   &types.AttributeValueMemberS{Value: req.CIDRBlock},
-		"vpc_name":   &types.AttributeValueMemberS{Value: req.VPCName},
-		"status":     &types.AttributeValueMemberS{Value: "created"},
-		"subnets":    &types.AttributeValueMemberL{Value: subnetItems},
-	}
-
-	_, err := dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(vpcTableName),
-		Item:      item,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to store VPC metadata: %w", err)
+// responseHeaders returns the headers every response carries: the usual
+// content-type/CORS pair, plus the X-Ray trace ID and the inbound request ID
+// so a caller can correlate a failure with server-side traces and logs.
+func responseHeaders(ctx context.Context) map[string]string {
+	headers := map[string]string{
+		"Content-Type":                "application/json",
+		"Access-Control-Allow-Origin": "*",
 	}
-
-	return nil
+	if traceId := xray.TraceID(ctx); traceId != "" {
+		headers["X-Amzn-Trace-Id"] = traceId
+	}
+	if requestId := obs.RequestID(ctx); requestId != "" {
+		headers["X-Request-Id"] = requestId
+	}
+	return headers
 }
 
-func successResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
+func successResponse(ctx context.Context, statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
-		return errorResponse(500, "Failed to marshal response", err.Error())
+		return errorResponse(ctx, 500, "Failed to marshal response", err.Error())
 	}
 
 	return events.APIGatewayProxyResponse{
 		StatusCode: statusCode,
-		Headers: map[string]string{
-			"Content-Type":                "application/json",
-			"Access-Control-Allow-Origin": "*",
-		},
-		Body: string(bodyBytes),
+		Headers:    responseHeaders(ctx),
+		Body:       string(bodyBytes),
 	}, nil
 }
 
-func errorResponse(statusCode int, message, details string) (events.APIGatewayProxyResponse, error) {
+func errorResponse(ctx context.Context, statusCode int, message, details string) (events.APIGatewayProxyResponse, error) {
 	errorResp := ErrorResponse{
 		Error:   message,
 		Message: details,
@@ -277,11 +335,8 @@ func errorResponse(statusCode int, message, details string) (events.APIGatewayPr
 
 	return events.APIGatewayProxyResponse{
 		StatusCode: statusCode,
-		Headers: map[string]string{
-			"Content-Type":                "application/json",
-			"Access-Control-Allow-Origin": "*",
-		},
-		Body: string(bodyBytes),
+		Headers:    responseHeaders(ctx),
+		Body:       string(bodyBytes),
 	}, nil
 }
 
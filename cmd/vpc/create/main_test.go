@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/velhalkaran28/AWSDemo/internal/awsvpc"
+	"github.com/velhalkaran28/AWSDemo/internal/testutil"
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
+
+func newTestHandler(fakeEC2 *testutil.FakeEC2, fakeDynamo *testutil.FakeDynamo) *Handler {
+	return &Handler{
+		EC2:   awsvpc.New(fakeEC2),
+		Store: vpcstore.New(fakeDynamo, "vpc-table"),
+	}
+}
+
+func validRequestBody() string {
+	body, _ := json.Marshal(CreateVPCRequest{
+		CIDRBlock: "10.0.0.0/16",
+		VPCName:   "test-vpc",
+		Subnets: []Subnet{
+			{CIDRBlock: "10.0.1.0/24", Name: "subnet-a"},
+			{CIDRBlock: "10.0.2.0/24", Name: "subnet-b"},
+		},
+	})
+	return string(body)
+}
+
+func TestHandle_ValidationFailure(t *testing.T) {
+	h := newTestHandler(&testutil.FakeEC2{}, testutil.NewFakeDynamo())
+
+	resp, err := h.handle(context.Background(), events.APIGatewayProxyRequest{
+		Body: `{"vpc_name": "missing-cidr"}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandle_RejectsOverlappingCIDR(t *testing.T) {
+	fakeDynamo := testutil.NewFakeDynamo()
+	h := newTestHandler(&testutil.FakeEC2{}, fakeDynamo)
+
+	first, err := h.handle(context.Background(), events.APIGatewayProxyRequest{Body: validRequestBody()})
+	if err != nil || first.StatusCode != 201 {
+		t.Fatalf("expected first create to succeed, got status %d err %v", first.StatusCode, err)
+	}
+
+	// A different request (different idempotency hash) that reuses the same
+	// VPC CIDR must be rejected as an overlap, not served from cache.
+	secondBody, _ := json.Marshal(CreateVPCRequest{
+		CIDRBlock: "10.0.0.0/16",
+		VPCName:   "another-vpc",
+		Subnets: []Subnet{
+			{CIDRBlock: "10.0.3.0/24", Name: "subnet-c"},
+		},
+	})
+
+	second, err := h.handle(context.Background(), events.APIGatewayProxyRequest{Body: string(secondBody)})
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if second.StatusCode != 409 {
+		t.Fatalf("expected 409 for overlapping cidr, got %d", second.StatusCode)
+	}
+}
+
+func TestHandle_IdempotentReplayDoesNotRecreateVPC(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	h := newTestHandler(fakeEC2, testutil.NewFakeDynamo())
+
+	request := events.APIGatewayProxyRequest{
+		Body:    validRequestBody(),
+		Headers: map[string]string{"Idempotency-Key": "replay-key"},
+	}
+
+	first, err := h.handle(context.Background(), request)
+	if err != nil || first.StatusCode != 201 {
+		t.Fatalf("expected first create to succeed, got status %d err %v", first.StatusCode, err)
+	}
+
+	second, err := h.handle(context.Background(), request)
+	if err != nil || second.StatusCode != 201 {
+		t.Fatalf("expected replay to return the cached 201, got status %d err %v", second.StatusCode, err)
+	}
+
+	if fakeEC2.CreateVpcCalls != 1 {
+		t.Fatalf("expected CreateVpc to be called once, got %d", fakeEC2.CreateVpcCalls)
+	}
+	if first.Body != second.Body {
+		t.Fatalf("expected replay body to match original: %q != %q", first.Body, second.Body)
+	}
+}
+
+func TestHandle_RollsBackOnSubnetFailure(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	calls := 0
+	fakeEC2.CreateSubnetFunc = func(ctx context.Context, params *ec2.CreateSubnetInput, optFns ...func(*ec2.Options)) (*ec2.CreateSubnetOutput, error) {
+		calls++
+		if calls == 1 {
+			return &ec2.CreateSubnetOutput{Subnet: &ec2types.Subnet{
+				SubnetId:         aws.String("subnet-ok"),
+				CidrBlock:        params.CidrBlock,
+				AvailabilityZone: params.AvailabilityZone,
+			}}, nil
+		}
+		return nil, errors.New("simulated CreateSubnet failure")
+	}
+
+	h := newTestHandler(fakeEC2, testutil.NewFakeDynamo())
+
+	resp, err := h.handle(context.Background(), events.APIGatewayProxyRequest{Body: validRequestBody()})
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 500 {
+		t.Fatalf("expected 500 on subnet failure, got %d", resp.StatusCode)
+	}
+	if fakeEC2.DeleteSubnetCalls != 1 {
+		t.Fatalf("expected rollback to delete the one successfully created subnet, got %d deletes", fakeEC2.DeleteSubnetCalls)
+	}
+	if fakeEC2.DeleteVpcCalls != 1 {
+		t.Fatalf("expected rollback to delete the VPC, got %d deletes", fakeEC2.DeleteVpcCalls)
+	}
+}
+
+func TestHandle_RetryAfterFailureSucceeds(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	calls := 0
+	fakeEC2.CreateVpcFunc = func(ctx context.Context, params *ec2.CreateVpcInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcOutput, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("simulated transient CreateVpc failure")
+		}
+		return &ec2.CreateVpcOutput{Vpc: &ec2types.Vpc{
+			VpcId:     aws.String("vpc-retry"),
+			CidrBlock: params.CidrBlock,
+		}}, nil
+	}
+
+	h := newTestHandler(fakeEC2, testutil.NewFakeDynamo())
+	request := events.APIGatewayProxyRequest{Body: validRequestBody()}
+
+	first, err := h.handle(context.Background(), request)
+	if err != nil || first.StatusCode != 500 {
+		t.Fatalf("expected first attempt to fail with 500, got status %d err %v", first.StatusCode, err)
+	}
+
+	// Same body -> same auto-derived idempotency key. Without re-claiming a
+	// failed key, this would 409 forever instead of retrying.
+	second, err := h.handle(context.Background(), request)
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if second.StatusCode != 201 {
+		t.Fatalf("expected retry after a failed attempt to succeed, got %d", second.StatusCode)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/velhalkaran28/AWSDemo/internal/awsvpc"
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
+
+type DeleteVPCResponse struct {
+	Message        string   `json:"message"`
+	VPCId          string   `json:"vpc_id"`
+	DeletedSubnets []string `json:"deleted_subnets"`
+	DryRun         bool     `json:"dry_run"`
+	Warnings       []string `json:"warnings,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// Handler bundles the AWS dependencies this Lambda needs behind small
+// interfaces (awsvpc.EC2API, vpcstore.DynamoAPI) so tests can inject fakes
+// instead of calling live AWS.
+type Handler struct {
+	EC2   *awsvpc.Client
+	Store *vpcstore.Store
+}
+
+var h *Handler
+
+func init() {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		panic(fmt.Sprintf("unable to load SDK config: %v", err))
+	}
+
+	h = &Handler{
+		EC2:   awsvpc.New(ec2.NewFromConfig(cfg)),
+		Store: vpcstore.New(dynamodb.NewFromConfig(cfg), os.Getenv("VPC_TABLE_NAME")),
+	}
+}
+
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return h.handle(ctx, request)
+}
+
+func (h *Handler) handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	vpcId := request.PathParameters["vpc_id"]
+	if vpcId == "" {
+		return errorResponse(400, "vpc_id is required", "")
+	}
+
+	force := request.QueryStringParameters["force"] == "true"
+	dryRun := request.QueryStringParameters["dry_run"] == "true"
+
+	vpc, err := h.Store.GetVPC(ctx, vpcId)
+	if err != nil {
+		return errorResponse(500, "Failed to query DynamoDB", err.Error())
+	}
+	if vpc == nil {
+		return errorResponse(404, "VPC not found", fmt.Sprintf("VPC with ID %s does not exist", vpcId))
+	}
+
+	subnetIds := make([]string, 0, len(vpc.Subnets))
+	for _, subnet := range vpc.Subnets {
+		subnetIds = append(subnetIds, subnet.SubnetId)
+	}
+
+	if dryRun {
+		return successResponse(200, DeleteVPCResponse{
+			Message:        "dry run: no resources were deleted",
+			VPCId:          vpcId,
+			DeletedSubnets: subnetIds,
+			DryRun:         true,
+		})
+	}
+
+	vpc.Status = vpcstore.StatusDeleting
+	if err := h.Store.PutStatus(ctx, *vpc); err != nil {
+		return errorResponse(500, "Failed to record deleting status", err.Error())
+	}
+
+	var warnings []string
+	deletedSubnets := make([]string, 0, len(subnetIds))
+	for _, subnetId := range subnetIds {
+		if err := h.EC2.DeleteSubnet(ctx, subnetId); err != nil {
+			if !force {
+				vpc.Status = vpcstore.StatusFailed
+				_ = h.Store.PutStatus(ctx, *vpc)
+				return errorResponse(500, "Failed to delete subnet", err.Error())
+			}
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		deletedSubnets = append(deletedSubnets, subnetId)
+	}
+
+	vpcDeleted := true
+	if err := h.EC2.DeleteVPC(ctx, vpcId); err != nil {
+		if !force {
+			vpc.Status = vpcstore.StatusFailed
+			_ = h.Store.PutStatus(ctx, *vpc)
+			return errorResponse(500, "Failed to delete VPC", err.Error())
+		}
+		vpcDeleted = false
+		warnings = append(warnings, err.Error())
+	}
+
+	if !vpcDeleted || len(deletedSubnets) != len(subnetIds) {
+		// force=true tolerated failures above, but the VPC and/or some
+		// subnets still exist in AWS. Keep the DynamoDB record (as failed)
+		// instead of deleting it, or the only way to find these resources
+		// again via list/get is lost.
+		vpc.Status = vpcstore.StatusFailed
+		if err := h.Store.PutStatus(ctx, *vpc); err != nil {
+			return errorResponse(500, "Failed to record partial delete status", err.Error())
+		}
+		return successResponse(200, DeleteVPCResponse{
+			Message:        "VPC delete incomplete: some resources were not deleted",
+			VPCId:          vpcId,
+			DeletedSubnets: deletedSubnets,
+			Warnings:       warnings,
+		})
+	}
+
+	if err := h.Store.DeleteRecord(ctx, vpc.VPCId, vpc.CreatedAt); err != nil {
+		return errorResponse(500, "Failed to delete VPC record", err.Error())
+	}
+
+	return successResponse(200, DeleteVPCResponse{
+		Message:        "VPC deleted",
+		VPCId:          vpcId,
+		DeletedSubnets: deletedSubnets,
+		Warnings:       warnings,
+	})
+}
+
+func successResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return errorResponse(500, "Failed to marshal response", err.Error())
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(bodyBytes),
+	}, nil
+}
+
+func errorResponse(statusCode int, message, details string) (events.APIGatewayProxyResponse, error) {
+	errorResp := ErrorResponse{
+		Error:   message,
+		Message: details,
+	}
+	bodyBytes, _ := json.Marshal(errorResp)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(bodyBytes),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/velhalkaran28/AWSDemo/internal/awsvpc"
+	"github.com/velhalkaran28/AWSDemo/internal/testutil"
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
+
+func newTestHandler(fakeEC2 *testutil.FakeEC2, fakeDynamo *testutil.FakeDynamo) *Handler {
+	return &Handler{
+		EC2:   awsvpc.New(fakeEC2),
+		Store: vpcstore.New(fakeDynamo, "vpc-table"),
+	}
+}
+
+func seedVPC(t *testing.T, store *vpcstore.Store, vpcId string, subnetIds ...string) {
+	t.Helper()
+	subnets := make([]vpcstore.SubnetResult, 0, len(subnetIds))
+	for _, id := range subnetIds {
+		subnets = append(subnets, vpcstore.SubnetResult{SubnetId: id, CIDRBlock: "10.0.1.0/24"})
+	}
+	err := store.PutStatus(context.Background(), vpcstore.VPCResource{
+		VPCId:     vpcId,
+		CreatedAt: "2024-01-01T00:00:00Z",
+		CreatedBy: "alice",
+		VPCCidr:   "10.0.0.0/16",
+		VPCName:   "test-vpc",
+		Status:    vpcstore.StatusCreated,
+		Subnets:   subnets,
+	})
+	if err != nil {
+		t.Fatalf("seedVPC(%s): %v", vpcId, err)
+	}
+}
+
+func deleteRequest(vpcId string, queryParams map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		PathParameters:        map[string]string{"vpc_id": vpcId},
+		QueryStringParameters: queryParams,
+	}
+}
+
+func decodeDeleteResponse(t *testing.T, resp events.APIGatewayProxyResponse) DeleteVPCResponse {
+	t.Helper()
+	var out DeleteVPCResponse
+	if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return out
+}
+
+func TestHandle_DryRunDeletesNothing(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	h := newTestHandler(fakeEC2, testutil.NewFakeDynamo())
+	seedVPC(t, h.Store, "vpc-1", "subnet-1")
+
+	resp, err := h.handle(context.Background(), deleteRequest("vpc-1", map[string]string{"dry_run": "true"}))
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+	if fakeEC2.DeleteVpcCalls != 0 || fakeEC2.DeleteSubnetCalls != 0 {
+		t.Fatalf("expected dry run to call no EC2 deletes, got vpc=%d subnet=%d", fakeEC2.DeleteVpcCalls, fakeEC2.DeleteSubnetCalls)
+	}
+
+	stored, err := h.Store.GetVPC(context.Background(), "vpc-1")
+	if err != nil || stored == nil {
+		t.Fatalf("expected the record to still exist after a dry run, got %+v err %v", stored, err)
+	}
+}
+
+func TestHandle_SuccessfulDeleteRemovesRecord(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	h := newTestHandler(fakeEC2, testutil.NewFakeDynamo())
+	seedVPC(t, h.Store, "vpc-1", "subnet-1", "subnet-2")
+
+	resp, err := h.handle(context.Background(), deleteRequest("vpc-1", nil))
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	out := decodeDeleteResponse(t, resp)
+	if len(out.DeletedSubnets) != 2 {
+		t.Fatalf("expected both subnets deleted, got %v", out.DeletedSubnets)
+	}
+
+	stored, err := h.Store.GetVPC(context.Background(), "vpc-1")
+	if err != nil {
+		t.Fatalf("unexpected error querying store: %v", err)
+	}
+	if stored != nil {
+		t.Fatalf("expected the record to be removed after a fully successful delete, got %+v", stored)
+	}
+}
+
+func TestHandle_ForceDeleteWithVPCFailureKeepsRecord(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	fakeEC2.DeleteVpcFunc = func(ctx context.Context, params *ec2.DeleteVpcInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVpcOutput, error) {
+		return nil, errors.New("simulated DeleteVpc failure")
+	}
+	h := newTestHandler(fakeEC2, testutil.NewFakeDynamo())
+	seedVPC(t, h.Store, "vpc-1", "subnet-1")
+
+	resp, err := h.handle(context.Background(), deleteRequest("vpc-1", map[string]string{"force": "true"}))
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected force delete to still return 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	out := decodeDeleteResponse(t, resp)
+	if len(out.Warnings) == 0 {
+		t.Fatalf("expected a warning about the failed VPC delete")
+	}
+
+	// The VPC still exists in AWS (DeleteVpc failed), so the tracking record
+	// must be kept, not erased, or the resource becomes unreachable via
+	// list/get.
+	stored, err := h.Store.GetVPC(context.Background(), "vpc-1")
+	if err != nil || stored == nil {
+		t.Fatalf("expected the record to be retained after a partial force delete, got %+v err %v", stored, err)
+	}
+	if stored.Status != vpcstore.StatusFailed {
+		t.Fatalf("expected status %q, got %q", vpcstore.StatusFailed, stored.Status)
+	}
+}
+
+func TestHandle_NonForceDeleteFailureKeepsRecordAndReturns500(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	fakeEC2.DeleteSubnetFunc = func(ctx context.Context, params *ec2.DeleteSubnetInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSubnetOutput, error) {
+		return nil, errors.New("simulated DeleteSubnet failure")
+	}
+	h := newTestHandler(fakeEC2, testutil.NewFakeDynamo())
+	seedVPC(t, h.Store, "vpc-1", "subnet-1")
+
+	resp, err := h.handle(context.Background(), deleteRequest("vpc-1", nil))
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 500 {
+		t.Fatalf("expected 500 on a non-force subnet delete failure, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	stored, err := h.Store.GetVPC(context.Background(), "vpc-1")
+	if err != nil || stored == nil {
+		t.Fatalf("expected the record to be retained after a hard failure, got %+v err %v", stored, err)
+	}
+	if stored.Status != vpcstore.StatusFailed {
+		t.Fatalf("expected status %q, got %q", vpcstore.StatusFailed, stored.Status)
+	}
+}
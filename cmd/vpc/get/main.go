@@ -2,39 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-)
-
-type VPCResource struct {
-	VPCId     string         `json:"vpc_id" dynamodbav:"vpc_id"`
-	CreatedAt string         `json:"created_at" dynamodbav:"created_at"`
-	CreatedBy string         `json:"created_by" dynamodbav:"created_by"`
-	VPCCidr   string         `json:"vpc_cidr" dynamodbav:"vpc_cidr"`
-	VPCName   string         `json:"vpc_name" dynamodbav:"vpc_name"`
-	Status    string         `json:"status" dynamodbav:"status"`
-	Subnets   []SubnetResult `json:"subnets" dynamodbav:"subnets"`
-}
 
-type SubnetResult struct {
-	SubnetId         string `json:"subnet_id" dynamodbav:"subnet_id"`
-	CIDRBlock        string `json:"cidr_block" dynamodbav:"cidr_block"`
-	AvailabilityZone string `json:"availability_zone" dynamodbav:"availability_zone"`
-	Name             string `json:"name" dynamodbav:"name"`
-}
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
 
 type ListVPCResponse struct {
-	VPCs  []VPCResource `json:"vpcs"`
-	Count int           `json:"count"`
+	VPCs      []vpcstore.VPCResource `json:"vpcs"`
+	Count     int                    `json:"count"`
+	NextToken string                 `json:"next_token,omitempty"`
+	Warnings  []string               `json:"warnings,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -42,84 +29,145 @@ type ErrorResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-var (
-	dynamoClient *dynamodb.Client
-	vpcTableName string
+const (
+	defaultLimit = 20
+	maxLimit     = 100
 )
 
+// Handler bundles the vpcstore.DynamoAPI dependency behind a small
+// interface so tests can inject a fake instead of calling live AWS.
+type Handler struct {
+	Store *vpcstore.Store
+}
+
+var h *Handler
+
 func init() {
 	cfg, err := config.LoadDefaultConfig(context.TODO())
 	if err != nil {
 		panic(fmt.Sprintf("unable to load SDK config: %v", err))
 	}
 
-	dynamoClient = dynamodb.NewFromConfig(cfg)
-	vpcTableName = os.Getenv("VPC_TABLE_NAME")
+	h = &Handler{Store: vpcstore.New(dynamodb.NewFromConfig(cfg), os.Getenv("VPC_TABLE_NAME"))}
 }
 
 func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return h.handle(ctx, request)
+}
+
+func (h *Handler) handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	vpcId := request.PathParameters["vpc_id"]
 
 	if vpcId != "" {
-		return getVPC(ctx, vpcId)
+		return h.getVPC(ctx, vpcId)
 	}
 
-	return listVPCs(ctx, request.QueryStringParameters)
+	return h.listVPCs(ctx, request.QueryStringParameters)
 }
 
-func getVPC(ctx context.Context, vpcId string) (events.APIGatewayProxyResponse, error) {
-	queryInput := &dynamodb.QueryInput{
-		TableName:              aws.String(vpcTableName),
-		KeyConditionExpression: aws.String("vpc_id = :vpc_id"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":vpc_id": &types.AttributeValueMemberS{Value: vpcId},
-		},
-		ScanIndexForward: aws.Bool(false),
-		Limit:            aws.Int32(1),
-	}
-
-	result, err := dynamoClient.Query(ctx, queryInput)
+func (h *Handler) getVPC(ctx context.Context, vpcId string) (events.APIGatewayProxyResponse, error) {
+	vpc, err := h.Store.GetVPC(ctx, vpcId)
 	if err != nil {
 		return errorResponse(500, "Failed to query DynamoDB", err.Error())
 	}
-
-	if len(result.Items) == 0 {
+	if vpc == nil {
 		return errorResponse(404, "VPC not found", fmt.Sprintf("VPC with ID %s does not exist", vpcId))
 	}
 
-	var vpc VPCResource
-	err = attributevalue.UnmarshalMap(result.Items[0], &vpc)
+	return successResponse(200, vpc)
+}
+
+func parseListFilters(queryParams map[string]string) (vpcstore.ListFilters, error) {
+	filters := vpcstore.ListFilters{Limit: defaultLimit}
+
+	if raw, ok := queryParams["limit"]; ok && raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return filters, fmt.Errorf("limit must be a positive integer")
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		filters.Limit = int32(limit)
+	}
+
+	if raw, ok := queryParams["next_token"]; ok && raw != "" {
+		startKey, err := decodeNextToken(raw)
+		if err != nil {
+			return filters, fmt.Errorf("invalid next_token: %w", err)
+		}
+		filters.StartKey = startKey
+	}
+
+	filters.Status = queryParams["status"]
+	filters.CreatedBy = queryParams["created_by"]
+	filters.NamePrefix = queryParams["name_prefix"]
+
+	return filters, nil
+}
+
+func decodeNextToken(token string) (map[string]types.AttributeValue, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
 	if err != nil {
-		return errorResponse(500, "Failed to parse VPC data", err.Error())
+		return nil, err
 	}
 
-	return successResponse(200, vpc)
+	var plain map[string]string
+	if err := json.Unmarshal(raw, &plain); err != nil {
+		return nil, err
+	}
+
+	startKey := make(map[string]types.AttributeValue, len(plain))
+	for k, v := range plain {
+		startKey[k] = &types.AttributeValueMemberS{Value: v}
+	}
+	return startKey, nil
 }
 
-func listVPCs(ctx context.Context, queryParams map[string]string) (events.APIGatewayProxyResponse, error) {
-	scanInput := &dynamodb.ScanInput{
-		TableName: aws.String(vpcTableName),
+func encodeNextToken(key map[string]types.AttributeValue) (string, error) {
+	plain := make(map[string]string, len(key))
+	for k, v := range key {
+		s, ok := v.(*types.AttributeValueMemberS)
+		if !ok {
+			return "", fmt.Errorf("unsupported LastEvaluatedKey attribute type for %q", k)
+		}
+		plain[k] = s.Value
+	}
+
+	raw, err := json.Marshal(plain)
+	if err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
 
-	result, err := dynamoClient.Scan(ctx, scanInput)
+func (h *Handler) listVPCs(ctx context.Context, queryParams map[string]string) (events.APIGatewayProxyResponse, error) {
+	filters, err := parseListFilters(queryParams)
 	if err != nil {
-		return errorResponse(500, "Failed to scan DynamoDB", err.Error())
+		return errorResponse(400, "Invalid query parameters", err.Error())
+	}
+
+	result, err := h.Store.List(ctx, filters)
+	if err != nil {
+		return errorResponse(500, "Failed to query DynamoDB", err.Error())
+	}
+
+	response := ListVPCResponse{
+		VPCs:     result.VPCs,
+		Count:    len(result.VPCs),
+		Warnings: result.Warnings,
 	}
 
-	vpcs := make([]VPCResource, 0, len(result.Items))
-	for _, item := range result.Items {
-		var vpc VPCResource
-		err := attributevalue.UnmarshalMap(item, &vpc)
+	if result.LastEvaluatedKey != nil {
+		nextToken, err := encodeNextToken(result.LastEvaluatedKey)
 		if err != nil {
-			continue
+			response.Warnings = append(response.Warnings, fmt.Sprintf("failed to encode next_token: %v", err))
+		} else {
+			response.NextToken = nextToken
 		}
-		vpcs = append(vpcs, vpc)
 	}
 
-	return successResponse(200, ListVPCResponse{
-		VPCs:  vpcs,
-		Count: len(vpcs),
-	})
+	return successResponse(200, response)
 }
 
 func successResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/velhalkaran28/AWSDemo/internal/testutil"
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
+
+func newTestHandler(fakeDynamo *testutil.FakeDynamo) *Handler {
+	return &Handler{Store: vpcstore.New(fakeDynamo, "vpc-table")}
+}
+
+func seedVPC(t *testing.T, store *vpcstore.Store, vpcId, createdAt, createdBy, vpcName, status string) {
+	t.Helper()
+	err := store.PutStatus(context.Background(), vpcstore.VPCResource{
+		VPCId:     vpcId,
+		CreatedAt: createdAt,
+		CreatedBy: createdBy,
+		VPCCidr:   "10.0.0.0/16",
+		VPCName:   vpcName,
+		Status:    status,
+	})
+	if err != nil {
+		t.Fatalf("seedVPC(%s): %v", vpcId, err)
+	}
+}
+
+func decodeListResponse(t *testing.T, resp events.APIGatewayProxyResponse) ListVPCResponse {
+	t.Helper()
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+	var out ListVPCResponse
+	if err := json.Unmarshal([]byte(resp.Body), &out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return out
+}
+
+func TestListVPCs_PaginatesResults(t *testing.T) {
+	fakeDynamo := testutil.NewFakeDynamo()
+	h := newTestHandler(fakeDynamo)
+
+	for i := 0; i < 5; i++ {
+		seedVPC(t, h.Store, fmt.Sprintf("vpc-%d", i), fmt.Sprintf("2024-01-0%dT00:00:00Z", i+1), "alice", "vpc", vpcstore.StatusCreated)
+	}
+
+	first := decodeListResponse(t, mustHandle(t, h, map[string]string{"limit": "2"}))
+	if first.Count != 2 || first.NextToken == "" {
+		t.Fatalf("expected a first page of 2 with a next_token, got count=%d next_token=%q", first.Count, first.NextToken)
+	}
+
+	second := decodeListResponse(t, mustHandle(t, h, map[string]string{"limit": "2", "next_token": first.NextToken}))
+	if second.Count != 2 || second.NextToken == "" {
+		t.Fatalf("expected a second page of 2 with a next_token, got count=%d next_token=%q", second.Count, second.NextToken)
+	}
+
+	third := decodeListResponse(t, mustHandle(t, h, map[string]string{"limit": "2", "next_token": second.NextToken}))
+	if third.Count != 1 || third.NextToken != "" {
+		t.Fatalf("expected a final page of 1 with no next_token, got count=%d next_token=%q", third.Count, third.NextToken)
+	}
+
+	seen := map[string]bool{}
+	for _, page := range [][]vpcstore.VPCResource{first.VPCs, second.VPCs, third.VPCs} {
+		for _, vpc := range page {
+			if seen[vpc.VPCId] {
+				t.Fatalf("vpc %s returned more than once across pages", vpc.VPCId)
+			}
+			seen[vpc.VPCId] = true
+		}
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected all 5 vpcs to be seen across pages, got %d", len(seen))
+	}
+}
+
+func TestListVPCs_FiltersByCreatedByGSI(t *testing.T) {
+	fakeDynamo := testutil.NewFakeDynamo()
+	h := newTestHandler(fakeDynamo)
+
+	seedVPC(t, h.Store, "vpc-alice-1", "2024-01-01T00:00:00Z", "alice", "vpc", vpcstore.StatusCreated)
+	seedVPC(t, h.Store, "vpc-alice-2", "2024-01-02T00:00:00Z", "alice", "vpc", vpcstore.StatusCreated)
+	seedVPC(t, h.Store, "vpc-bob-1", "2024-01-03T00:00:00Z", "bob", "vpc", vpcstore.StatusCreated)
+
+	resp := decodeListResponse(t, mustHandle(t, h, map[string]string{"created_by": "alice"}))
+	if resp.Count != 2 {
+		t.Fatalf("expected 2 vpcs for created_by=alice, got %d", resp.Count)
+	}
+	for _, vpc := range resp.VPCs {
+		if vpc.CreatedBy != "alice" {
+			t.Fatalf("expected only alice's vpcs, got created_by=%s", vpc.CreatedBy)
+		}
+	}
+}
+
+func TestListVPCs_FiltersByStatusAndNamePrefix(t *testing.T) {
+	fakeDynamo := testutil.NewFakeDynamo()
+	h := newTestHandler(fakeDynamo)
+
+	seedVPC(t, h.Store, "vpc-1", "2024-01-01T00:00:00Z", "alice", "dev-vpc", vpcstore.StatusCreated)
+	seedVPC(t, h.Store, "vpc-2", "2024-01-02T00:00:00Z", "alice", "dev-test", vpcstore.StatusFailed)
+	seedVPC(t, h.Store, "vpc-3", "2024-01-03T00:00:00Z", "alice", "prod-vpc", vpcstore.StatusCreated)
+
+	resp := decodeListResponse(t, mustHandle(t, h, map[string]string{"status": vpcstore.StatusCreated, "name_prefix": "dev-"}))
+	if resp.Count != 1 || resp.VPCs[0].VPCId != "vpc-1" {
+		t.Fatalf("expected only vpc-1 to match status+name_prefix filters, got %+v", resp.VPCs)
+	}
+}
+
+func TestListVPCs_SurfacesWarningsForUnparsableRecords(t *testing.T) {
+	fakeDynamo := testutil.NewFakeDynamo()
+	h := newTestHandler(fakeDynamo)
+
+	seedVPC(t, h.Store, "vpc-good", "2024-01-01T00:00:00Z", "alice", "vpc", vpcstore.StatusCreated)
+
+	// A record whose "subnets" attribute is the wrong shape (String instead
+	// of List) so attributevalue.UnmarshalMap fails for it specifically.
+	_, err := fakeDynamo.PutItem(context.Background(), &dynamodb.PutItemInput{
+		TableName: aws.String("vpc-table"),
+		Item: map[string]types.AttributeValue{
+			"vpc_id":     &types.AttributeValueMemberS{Value: "vpc-bad"},
+			"created_at": &types.AttributeValueMemberS{Value: "2024-01-02T00:00:00Z"},
+			"subnets":    &types.AttributeValueMemberS{Value: "not-a-list"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to seed malformed record: %v", err)
+	}
+
+	resp := decodeListResponse(t, mustHandle(t, h, nil))
+	if resp.Count != 1 || resp.VPCs[0].VPCId != "vpc-good" {
+		t.Fatalf("expected the malformed record to be skipped, got %+v", resp.VPCs)
+	}
+	if len(resp.Warnings) != 1 {
+		t.Fatalf("expected one warning for the malformed record, got %v", resp.Warnings)
+	}
+}
+
+func mustHandle(t *testing.T, h *Handler, queryParams map[string]string) events.APIGatewayProxyResponse {
+	t.Helper()
+	resp, err := h.handle(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: queryParams})
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	return resp
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/velhalkaran28/AWSDemo/internal/awsvpc"
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
+
+// UpdateVPCRequest is a partial update: only the fields the caller sets are
+// applied.
+type UpdateVPCRequest struct {
+	VPCName            *string           `json:"vpc_name,omitempty"`
+	EnableDNSHostnames *bool             `json:"enable_dns_hostnames,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// Handler bundles the AWS dependencies this Lambda needs behind small
+// interfaces (awsvpc.EC2API, vpcstore.DynamoAPI) so tests can inject fakes
+// instead of calling live AWS.
+type Handler struct {
+	EC2   *awsvpc.Client
+	Store *vpcstore.Store
+}
+
+var h *Handler
+
+func init() {
+	cfg, err := config.LoadDefaultConfig(context.TODO())
+	if err != nil {
+		panic(fmt.Sprintf("unable to load SDK config: %v", err))
+	}
+
+	h = &Handler{
+		EC2:   awsvpc.New(ec2.NewFromConfig(cfg)),
+		Store: vpcstore.New(dynamodb.NewFromConfig(cfg), os.Getenv("VPC_TABLE_NAME")),
+	}
+}
+
+func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return h.handle(ctx, request)
+}
+
+func (h *Handler) handle(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	vpcId := request.PathParameters["vpc_id"]
+	if vpcId == "" {
+		return errorResponse(400, "vpc_id is required", "")
+	}
+
+	var updateRequest UpdateVPCRequest
+	if err := json.Unmarshal([]byte(request.Body), &updateRequest); err != nil {
+		return errorResponse(400, "Invalid JSON", err.Error())
+	}
+
+	vpc, err := h.Store.GetVPC(ctx, vpcId)
+	if err != nil {
+		return errorResponse(500, "Failed to query DynamoDB", err.Error())
+	}
+	if vpc == nil {
+		return errorResponse(404, "VPC not found", fmt.Sprintf("VPC with ID %s does not exist", vpcId))
+	}
+
+	if updateRequest.VPCName != nil {
+		if err := h.EC2.RenameVPC(ctx, vpcId, *updateRequest.VPCName); err != nil {
+			return errorResponse(500, "Failed to rename VPC", err.Error())
+		}
+		// The rename already happened in EC2; record it even if a later step
+		// in this request fails, so the stored record doesn't lie about the
+		// VPC's current name.
+		vpc.VPCName = *updateRequest.VPCName
+		if persistErr := h.persistPartialUpdate(ctx, vpc); persistErr != nil {
+			return errorResponse(500, "Renamed VPC but failed to store updated metadata", persistErr.Error())
+		}
+	}
+
+	if updateRequest.EnableDNSHostnames != nil {
+		if err := h.EC2.SetDNSHostnames(ctx, vpcId, *updateRequest.EnableDNSHostnames); err != nil {
+			return errorResponse(500, "Failed to update DNS hostnames attribute", err.Error())
+		}
+	}
+
+	if len(updateRequest.Tags) > 0 {
+		if err := h.EC2.SetTags(ctx, vpcId, updateRequest.Tags); err != nil {
+			return errorResponse(500, "Failed to update tags", err.Error())
+		}
+	}
+
+	vpc.Status = vpcstore.StatusUpdated
+	vpc.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+	if err := h.Store.PutStatus(ctx, *vpc); err != nil {
+		return errorResponse(500, "Failed to store updated VPC metadata", err.Error())
+	}
+
+	return successResponse(200, vpc)
+}
+
+// persistPartialUpdate writes vpc's current fields as-is, with only
+// UpdatedAt bumped. It's used to durably record a mutation that already
+// succeeded in EC2 before a later step in the same request fails, so the
+// DynamoDB record never falls behind reality.
+func (h *Handler) persistPartialUpdate(ctx context.Context, vpc *vpcstore.VPCResource) error {
+	vpc.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	return h.Store.PutStatus(ctx, *vpc)
+}
+
+func successResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return errorResponse(500, "Failed to marshal response", err.Error())
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(bodyBytes),
+	}, nil
+}
+
+func errorResponse(statusCode int, message, details string) (events.APIGatewayProxyResponse, error) {
+	errorResp := ErrorResponse{
+		Error:   message,
+		Message: details,
+	}
+	bodyBytes, _ := json.Marshal(errorResp)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(bodyBytes),
+	}, nil
+}
+
+func main() {
+	lambda.Start(handler)
+}
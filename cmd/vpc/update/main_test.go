@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+
+	"github.com/velhalkaran28/AWSDemo/internal/awsvpc"
+	"github.com/velhalkaran28/AWSDemo/internal/testutil"
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
+
+func newTestHandler(fakeEC2 *testutil.FakeEC2, fakeDynamo *testutil.FakeDynamo) *Handler {
+	return &Handler{
+		EC2:   awsvpc.New(fakeEC2),
+		Store: vpcstore.New(fakeDynamo, "vpc-table"),
+	}
+}
+
+func seedVPC(t *testing.T, store *vpcstore.Store, vpcId string) {
+	t.Helper()
+	err := store.PutStatus(context.Background(), vpcstore.VPCResource{
+		VPCId:     vpcId,
+		CreatedAt: "2024-01-01T00:00:00Z",
+		CreatedBy: "alice",
+		VPCCidr:   "10.0.0.0/16",
+		VPCName:   "old-name",
+		Status:    vpcstore.StatusCreated,
+	})
+	if err != nil {
+		t.Fatalf("seedVPC(%s): %v", vpcId, err)
+	}
+}
+
+func TestHandle_UpdatesNameDNSAndTags(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	fakeDynamo := testutil.NewFakeDynamo()
+	h := newTestHandler(fakeEC2, fakeDynamo)
+	seedVPC(t, h.Store, "vpc-1")
+
+	newName := "new-name"
+	enableDNS := true
+	body, _ := json.Marshal(UpdateVPCRequest{
+		VPCName:            &newName,
+		EnableDNSHostnames: &enableDNS,
+		Tags:               map[string]string{"env": "prod"},
+	})
+
+	resp, err := h.handle(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"vpc_id": "vpc-1"},
+		Body:           string(body),
+	})
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	var updated vpcstore.VPCResource
+	if err := json.Unmarshal([]byte(resp.Body), &updated); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if updated.VPCName != newName {
+		t.Fatalf("expected vpc_name %q, got %q", newName, updated.VPCName)
+	}
+	if updated.Status != vpcstore.StatusUpdated {
+		t.Fatalf("expected status %q, got %q", vpcstore.StatusUpdated, updated.Status)
+	}
+	if updated.UpdatedAt == "" {
+		t.Fatalf("expected updated_at to be set")
+	}
+
+	stored, err := h.Store.GetVPC(context.Background(), "vpc-1")
+	if err != nil || stored == nil {
+		t.Fatalf("expected stored record, got %+v err %v", stored, err)
+	}
+	if stored.VPCName != newName || stored.Status != vpcstore.StatusUpdated {
+		t.Fatalf("expected stored record to reflect the update, got %+v", stored)
+	}
+}
+
+func TestHandle_Update_NotFound(t *testing.T) {
+	h := newTestHandler(&testutil.FakeEC2{}, testutil.NewFakeDynamo())
+
+	body, _ := json.Marshal(UpdateVPCRequest{})
+	resp, err := h.handle(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"vpc_id": "missing-vpc"},
+		Body:           string(body),
+	})
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 404 {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandle_Update_LaterFailureStillPersistsEarlierSuccessfulRename(t *testing.T) {
+	fakeEC2 := &testutil.FakeEC2{}
+	fakeEC2.CreateTagsFunc = func(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+		// RenameVPC and SetTags both call CreateTags; fail only the tags
+		// update (no "Name" tag in the request) so the rename still succeeds.
+		for _, tag := range params.Tags {
+			if tag.Key != nil && *tag.Key == "Name" {
+				return &ec2.CreateTagsOutput{}, nil
+			}
+		}
+		return nil, errors.New("simulated CreateTags failure")
+	}
+	fakeDynamo := testutil.NewFakeDynamo()
+	h := newTestHandler(fakeEC2, fakeDynamo)
+	seedVPC(t, h.Store, "vpc-1")
+
+	newName := "new-name"
+	body, _ := json.Marshal(UpdateVPCRequest{
+		VPCName: &newName,
+		Tags:    map[string]string{"env": "prod"},
+	})
+
+	resp, err := h.handle(context.Background(), events.APIGatewayProxyRequest{
+		PathParameters: map[string]string{"vpc_id": "vpc-1"},
+		Body:           string(body),
+	})
+	if err != nil {
+		t.Fatalf("unexpected transport error: %v", err)
+	}
+	if resp.StatusCode != 500 {
+		t.Fatalf("expected 500 on tag failure, got %d: %s", resp.StatusCode, resp.Body)
+	}
+
+	// RenameVPC already succeeded in EC2 before CreateTags failed, so the
+	// stored record must reflect the new name rather than silently going
+	// stale relative to the actual VPC.
+	stored, err := h.Store.GetVPC(context.Background(), "vpc-1")
+	if err != nil || stored == nil {
+		t.Fatalf("expected stored record to still exist, got %+v err %v", stored, err)
+	}
+	if stored.VPCName != newName {
+		t.Fatalf("expected the successful rename to be persisted despite the later tag failure, got vpc_name=%q", stored.VPCName)
+	}
+}
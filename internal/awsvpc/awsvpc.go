@@ -0,0 +1,204 @@
+// Package awsvpc wraps the EC2 calls needed to create, modify, and tear
+// down VPCs and their subnets, keeping the Lambda handlers in cmd/vpc/*
+// free of raw SDK request/response shaping.
+package awsvpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"github.com/velhalkaran28/AWSDemo/internal/vpcstore"
+)
+
+// ManagedByTag marks every resource this API creates so it can be told
+// apart from VPCs/subnets created outside of it.
+const ManagedByTag = "VPC-Management-API"
+
+// Client wraps an EC2API with this package's higher-level operations.
+type Client struct {
+	EC2 EC2API
+}
+
+// New builds a Client around an EC2API implementation (typically
+// *ec2.Client in production, a fake from internal/testutil in tests).
+func New(ec2Client EC2API) *Client {
+	return &Client{EC2: ec2Client}
+}
+
+// Subnet is the caller-supplied shape of a subnet to create.
+type Subnet struct {
+	CIDRBlock        string
+	Name             string
+	AvailabilityZone string
+}
+
+// CreateVPC creates a VPC tagged with name, returning its ID.
+func (c *Client) CreateVPC(ctx context.Context, cidrBlock, name string) (string, error) {
+	output, err := c.EC2.CreateVpc(ctx, &ec2.CreateVpcInput{
+		CidrBlock: aws.String(cidrBlock),
+		TagSpecifications: []types.TagSpecification{
+			{
+				ResourceType: types.ResourceTypeVpc,
+				Tags: []types.Tag{
+					{Key: aws.String("Name"), Value: aws.String(name)},
+					{Key: aws.String("ManagedBy"), Value: aws.String(ManagedByTag)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create VPC: %v", err)
+	}
+
+	return aws.ToString(output.Vpc.VpcId), nil
+}
+
+// CreateSubnets creates each subnet in vpcId, spreading across available
+// AZs when the caller does not pin one. It returns the subnets created so
+// far even when it returns an error, so the caller can roll back exactly
+// the resources that exist.
+func (c *Client) CreateSubnets(ctx context.Context, vpcId string, subnets []Subnet) ([]vpcstore.SubnetResult, error) {
+	results := make([]vpcstore.SubnetResult, 0, len(subnets))
+
+	azOutput, err := c.EC2.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("state"),
+				Values: []string{"available"},
+			},
+		},
+	})
+	if err != nil {
+		return results, fmt.Errorf("failed to describe availability zones: %w", err)
+	}
+
+	availableAZs := azOutput.AvailabilityZones
+
+	for i, subnet := range subnets {
+		var az string
+		if subnet.AvailabilityZone != "" {
+			az = subnet.AvailabilityZone
+		} else if len(availableAZs) == 0 {
+			return results, fmt.Errorf("failed to create subnet %s: no available availability zones returned for this region", subnet.Name)
+		} else if i < len(availableAZs) {
+			az = aws.ToString(availableAZs[i].ZoneName)
+		} else {
+			az = aws.ToString(availableAZs[0].ZoneName)
+		}
+
+		output, err := c.EC2.CreateSubnet(ctx, &ec2.CreateSubnetInput{
+			VpcId:            aws.String(vpcId),
+			CidrBlock:        aws.String(subnet.CIDRBlock),
+			AvailabilityZone: aws.String(az),
+			TagSpecifications: []types.TagSpecification{
+				{
+					ResourceType: types.ResourceTypeSubnet,
+					Tags: []types.Tag{
+						{Key: aws.String("Name"), Value: aws.String(subnet.Name)},
+						{Key: aws.String("ManagedBy"), Value: aws.String(ManagedByTag)},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return results, fmt.Errorf("failed to create subnet %s: %w", subnet.Name, err)
+		}
+
+		results = append(results, vpcstore.SubnetResult{
+			SubnetId:         aws.ToString(output.Subnet.SubnetId),
+			CIDRBlock:        subnet.CIDRBlock,
+			AvailabilityZone: az,
+			Name:             subnet.Name,
+		})
+	}
+
+	return results, nil
+}
+
+// Rollback compensates for a partially created VPC by deleting any subnets
+// that were created before tearing down the VPC itself. It is best-effort:
+// failures are reported through onError rather than returned, since the
+// caller is already on an error path and has no further compensating
+// action to take.
+func (c *Client) Rollback(ctx context.Context, vpcId string, subnets []vpcstore.SubnetResult, onError func(resource string, err error)) {
+	for _, subnet := range subnets {
+		if _, err := c.EC2.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{
+			SubnetId: aws.String(subnet.SubnetId),
+		}); err != nil && onError != nil {
+			onError("subnet "+subnet.SubnetId, err)
+		}
+	}
+
+	if vpcId == "" {
+		return
+	}
+
+	if _, err := c.EC2.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(vpcId)}); err != nil && onError != nil {
+		onError("vpc "+vpcId, err)
+	}
+}
+
+// DeleteSubnet deletes a single subnet by ID.
+func (c *Client) DeleteSubnet(ctx context.Context, subnetId string) error {
+	_, err := c.EC2.DeleteSubnet(ctx, &ec2.DeleteSubnetInput{SubnetId: aws.String(subnetId)})
+	if err != nil {
+		return fmt.Errorf("failed to delete subnet %s: %w", subnetId, err)
+	}
+	return nil
+}
+
+// DeleteVPC deletes a VPC by ID.
+func (c *Client) DeleteVPC(ctx context.Context, vpcId string) error {
+	_, err := c.EC2.DeleteVpc(ctx, &ec2.DeleteVpcInput{VpcId: aws.String(vpcId)})
+	if err != nil {
+		return fmt.Errorf("failed to delete vpc %s: %w", vpcId, err)
+	}
+	return nil
+}
+
+// RenameVPC updates a VPC's Name tag.
+func (c *Client) RenameVPC(ctx context.Context, vpcId, name string) error {
+	_, err := c.EC2.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{vpcId},
+		Tags: []types.Tag{
+			{Key: aws.String("Name"), Value: aws.String(name)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename vpc %s: %w", vpcId, err)
+	}
+	return nil
+}
+
+// SetTags applies or overwrites the given tags on a VPC.
+func (c *Client) SetTags(ctx context.Context, vpcId string, tags map[string]string) error {
+	ec2Tags := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		ec2Tags = append(ec2Tags, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	_, err := c.EC2.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{vpcId},
+		Tags:      ec2Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag vpc %s: %w", vpcId, err)
+	}
+	return nil
+}
+
+// SetDNSHostnames enables or disables DNS hostname assignment for a VPC.
+func (c *Client) SetDNSHostnames(ctx context.Context, vpcId string, enabled bool) error {
+	_, err := c.EC2.ModifyVpcAttribute(ctx, &ec2.ModifyVpcAttributeInput{
+		VpcId:              aws.String(vpcId),
+		EnableDnsHostnames: &types.AttributeBooleanValue{Value: aws.Bool(enabled)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to modify vpc %s dns hostnames attribute: %w", vpcId, err)
+	}
+	return nil
+}
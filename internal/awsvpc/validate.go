@@ -0,0 +1,74 @@
+package awsvpc
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// rfc1918Blocks are the private address ranges AWS VPCs are expected to be
+// carved out of. A CIDR outside all three is rejected up front rather than
+// forwarded to EC2 only to fail there.
+var rfc1918Blocks = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+}
+
+const (
+	minVPCPrefixLen = 16
+	maxVPCPrefixLen = 28
+)
+
+// ValidateVPCCIDR parses cidr, enforces RFC1918 ranges, and enforces AWS's
+// /16-/28 VPC size limits. It returns the parsed prefix so callers can reuse
+// it for subnet containment checks without re-parsing.
+func ValidateVPCCIDR(cidr string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid cidr_block %q: %w", cidr, err)
+	}
+
+	if prefix.Addr() != prefix.Masked().Addr() {
+		return netip.Prefix{}, fmt.Errorf("cidr_block %s is not a valid network address for its prefix length", cidr)
+	}
+
+	if prefix.Bits() < minVPCPrefixLen || prefix.Bits() > maxVPCPrefixLen {
+		return netip.Prefix{}, fmt.Errorf("cidr_block %s must be between /%d and /%d", cidr, minVPCPrefixLen, maxVPCPrefixLen)
+	}
+
+	inRFC1918 := false
+	for _, block := range rfc1918Blocks {
+		if block.Overlaps(prefix) {
+			inRFC1918 = true
+			break
+		}
+	}
+	if !inRFC1918 {
+		return netip.Prefix{}, fmt.Errorf("cidr_block %s must fall within an RFC1918 private range (10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16)", cidr)
+	}
+
+	return prefix, nil
+}
+
+// ValidateSubnets checks that every subnet CIDR parses, is fully contained
+// within vpcPrefix, and does not overlap any other subnet in the list.
+func ValidateSubnets(vpcPrefix netip.Prefix, subnets []Subnet) error {
+	seen := make([]netip.Prefix, 0, len(subnets))
+	for i, subnet := range subnets {
+		subnetPrefix, err := netip.ParsePrefix(subnet.CIDRBlock)
+		if err != nil {
+			return fmt.Errorf("subnet[%d]: invalid cidr_block %q: %w", i, subnet.CIDRBlock, err)
+		}
+		if !vpcPrefix.Contains(subnetPrefix.Addr()) || subnetPrefix.Bits() < vpcPrefix.Bits() {
+			return fmt.Errorf("subnet[%d]: cidr_block %s is not contained within vpc cidr %s", i, subnet.CIDRBlock, vpcPrefix)
+		}
+
+		for j, other := range seen {
+			if subnetPrefix.Overlaps(other) {
+				return fmt.Errorf("subnet[%d]: cidr_block %s overlaps subnet[%d] %s", i, subnet.CIDRBlock, j, subnets[j].CIDRBlock)
+			}
+		}
+		seen = append(seen, subnetPrefix)
+	}
+	return nil
+}
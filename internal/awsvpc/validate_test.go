@@ -0,0 +1,88 @@
+package awsvpc
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestValidateVPCCIDR(t *testing.T) {
+	tests := []struct {
+		name    string
+		cidr    string
+		wantErr bool
+	}{
+		{name: "valid /16 in 10.0.0.0/8", cidr: "10.0.0.0/16", wantErr: false},
+		{name: "valid /28 in 192.168.0.0/16", cidr: "192.168.1.0/28", wantErr: false},
+		{name: "too large: /15", cidr: "10.0.0.0/15", wantErr: true},
+		{name: "too small: /29", cidr: "10.0.0.0/29", wantErr: true},
+		{name: "not RFC1918", cidr: "8.8.8.0/24", wantErr: true},
+		{name: "not a network address for its prefix length", cidr: "10.0.0.1/24", wantErr: true},
+		{name: "unparsable cidr", cidr: "not-a-cidr", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateVPCCIDR(tt.cidr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateVPCCIDR(%q) error = %v, wantErr %v", tt.cidr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSubnets(t *testing.T) {
+	vpcPrefix := netip.MustParsePrefix("10.0.0.0/16")
+
+	tests := []struct {
+		name    string
+		subnets []Subnet
+		wantErr bool
+	}{
+		{
+			name: "non-overlapping subnets within the vpc",
+			subnets: []Subnet{
+				{CIDRBlock: "10.0.1.0/24", Name: "a"},
+				{CIDRBlock: "10.0.2.0/24", Name: "b"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "subnet outside the vpc cidr",
+			subnets: []Subnet{
+				{CIDRBlock: "10.1.1.0/24", Name: "a"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "subnet larger than the vpc",
+			subnets: []Subnet{
+				{CIDRBlock: "10.0.0.0/15", Name: "a"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "two overlapping subnets",
+			subnets: []Subnet{
+				{CIDRBlock: "10.0.1.0/24", Name: "a"},
+				{CIDRBlock: "10.0.1.128/25", Name: "b"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unparsable subnet cidr",
+			subnets: []Subnet{
+				{CIDRBlock: "not-a-cidr", Name: "a"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSubnets(vpcPrefix, tt.subnets)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateSubnets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Package obs provides the small observability primitives shared by the VPC
+// management Lambdas: a request-scoped slog.Logger carried on the context,
+// and a CloudWatch Embedded Metric Format emitter.
+package obs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+type loggerKey struct{}
+type requestIdKey struct{}
+
+// NewRequestLogger returns a JSON logger tagged with the API Gateway request
+// ID, so every line it emits can be correlated back to a single invocation
+// across a distributed trace.
+func NewRequestLogger(requestId string) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stderr, nil)).With("request_id", requestId)
+}
+
+// WithLogger returns a context carrying logger, retrievable with Logger. It
+// also stores requestId so RequestID can recover it without parsing the
+// logger's attributes.
+func WithLogger(ctx context.Context, logger *slog.Logger, requestId string) context.Context {
+	ctx = context.WithValue(ctx, loggerKey{}, logger)
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// Logger returns the logger stored on ctx by WithLogger. If none was set it
+// falls back to a default JSON logger with no request_id attribute.
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.New(slog.NewJSONHandler(os.Stderr, nil))
+}
+
+// RequestID returns the API Gateway request ID stored on ctx by WithLogger,
+// or "" if none was set.
+func RequestID(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
@@ -0,0 +1,48 @@
+package obs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MetricNamespace is the CloudWatch namespace every EMF log line from this
+// API is published under.
+const MetricNamespace = "VPCManagementAPI"
+
+// EmitCount writes a CloudWatch Embedded Metric Format log line to stdout
+// for a Count metric, tagged with the given dimensions. Lambda's CloudWatch
+// Logs agent parses EMF directly out of stdout, so no PutMetricData call is
+// needed.
+func EmitCount(metricName string, value float64, dimensions map[string]string) {
+	dimensionKeys := make([]string, 0, len(dimensions))
+	for k := range dimensions {
+		dimensionKeys = append(dimensionKeys, k)
+	}
+
+	doc := map[string]interface{}{
+		"_aws": map[string]interface{}{
+			"Timestamp": time.Now().UnixMilli(),
+			"CloudWatchMetrics": []map[string]interface{}{
+				{
+					"Namespace":  MetricNamespace,
+					"Dimensions": [][]string{dimensionKeys},
+					"Metrics": []map[string]interface{}{
+						{"Name": metricName, "Unit": "Count"},
+					},
+				},
+			},
+		},
+		metricName: value,
+	}
+	for k, v := range dimensions {
+		doc[k] = v
+	}
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		fmt.Printf("warning: failed to marshal EMF metric %s: %v\n", metricName, err)
+		return
+	}
+	fmt.Println(string(body))
+}
@@ -0,0 +1,260 @@
+package testutil
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type itemKey struct {
+	pk string
+	sk string
+}
+
+// FakeDynamo is a minimal in-memory stand-in for vpcstore.DynamoAPI. It
+// supports the access patterns vpcstore.Store issues against a table keyed
+// on vpc_id (hash) + created_at (range): a conditional PutItem on
+// "attribute_not_exists(vpc_id)", GetItem/DeleteItem by key, Query by
+// vpc_id or (via the created_by GSI) by created_by, and a Scan — both Query
+// and Scan honor Limit, ExclusiveStartKey/LastEvaluatedKey, and the small
+// subset of FilterExpression syntax vpcstore.buildFilterExpression
+// produces ("NOT begins_with(...)", "begins_with(...)", "attr = :val",
+// joined by " AND "). It is not a general DynamoDB expression evaluator.
+// Every method locks a shared mutex so it's safe for concurrent callers in
+// tests (e.g. exercising ReserveIdempotencyKey's conditional reclaim).
+type FakeDynamo struct {
+	mu    sync.Mutex
+	items map[itemKey]map[string]types.AttributeValue
+}
+
+// NewFakeDynamo returns an empty FakeDynamo.
+func NewFakeDynamo() *FakeDynamo {
+	return &FakeDynamo{items: map[itemKey]map[string]types.AttributeValue{}}
+}
+
+func attrString(item map[string]types.AttributeValue, key string) string {
+	s, _ := item[key].(*types.AttributeValueMemberS)
+	if s == nil {
+		return ""
+	}
+	return s.Value
+}
+
+func (f *FakeDynamo) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := itemKey{pk: attrString(params.Item, "vpc_id"), sk: attrString(params.Item, "created_at")}
+
+	if params.ConditionExpression != nil {
+		switch *params.ConditionExpression {
+		case "attribute_not_exists(vpc_id)":
+			if _, exists := f.items[key]; exists {
+				return nil, &types.ConditionalCheckFailedException{Message: dynamodbPtr("the conditional request failed")}
+			}
+		default:
+			existing, exists := f.items[key]
+			if !exists || !matchesFilter(existing, *params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues) {
+				return nil, &types.ConditionalCheckFailedException{Message: dynamodbPtr("the conditional request failed")}
+			}
+		}
+	}
+
+	f.items[key] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func dynamodbPtr(s string) *string { return &s }
+
+func (f *FakeDynamo) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := itemKey{pk: attrString(params.Key, "vpc_id"), sk: attrString(params.Key, "created_at")}
+	item, ok := f.items[key]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: item}, nil
+}
+
+func (f *FakeDynamo) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := itemKey{pk: attrString(params.Key, "vpc_id"), sk: attrString(params.Key, "created_at")}
+	delete(f.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *FakeDynamo) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if v, ok := params.ExpressionAttributeValues[":vpc_id"].(*types.AttributeValueMemberS); ok {
+		var matches []map[string]types.AttributeValue
+		for key, item := range f.items {
+			if key.pk == v.Value {
+				matches = append(matches, item)
+			}
+		}
+		sortByCreatedAt(matches, params.ScanIndexForward)
+		if params.Limit != nil && int(*params.Limit) < len(matches) {
+			matches = matches[:*params.Limit]
+		}
+		return &dynamodb.QueryOutput{Items: matches, Count: int32(len(matches))}, nil
+	}
+
+	// GSI query by created_by; this fake ignores the index name and simply
+	// filters the same in-memory items by that attribute, then applies any
+	// FilterExpression and pagination exactly like Scan.
+	v, _ := params.ExpressionAttributeValues[":created_by"].(*types.AttributeValueMemberS)
+	var keys []itemKey
+	for key, item := range f.items {
+		if v != nil && attrString(item, "created_by") != v.Value {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return attrString(f.items[keys[i]], "created_at") < attrString(f.items[keys[j]], "created_at")
+	})
+	if params.ScanIndexForward != nil && !*params.ScanIndexForward {
+		reverse(keys)
+	}
+
+	page, lastEvaluatedKey := f.paginate(keys, params.ExclusiveStartKey, params.Limit, params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	return &dynamodb.QueryOutput{Items: page, Count: int32(len(page)), LastEvaluatedKey: lastEvaluatedKey}, nil
+}
+
+func (f *FakeDynamo) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]itemKey, 0, len(f.items))
+	for key := range f.items {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].pk != keys[j].pk {
+			return keys[i].pk < keys[j].pk
+		}
+		return keys[i].sk < keys[j].sk
+	})
+
+	page, lastEvaluatedKey := f.paginate(keys, params.ExclusiveStartKey, params.Limit, params.FilterExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	return &dynamodb.ScanOutput{Items: page, Count: int32(len(page)), LastEvaluatedKey: lastEvaluatedKey}, nil
+}
+
+// paginate walks keys (already in the order the caller wants them
+// returned), skips past ExclusiveStartKey if present, keeps the items
+// matching filterExpr, and truncates to limit, returning a LastEvaluatedKey
+// when more matching items remain.
+func (f *FakeDynamo) paginate(keys []itemKey, exclusiveStartKey map[string]types.AttributeValue, limit *int32, filterExpr *string, names map[string]string, values map[string]types.AttributeValue) ([]map[string]types.AttributeValue, map[string]types.AttributeValue) {
+	start := 0
+	if exclusiveStartKey != nil {
+		startKey := itemKey{pk: attrString(exclusiveStartKey, "vpc_id"), sk: attrString(exclusiveStartKey, "created_at")}
+		for i, key := range keys {
+			if key == startKey {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	expr := ""
+	if filterExpr != nil {
+		expr = *filterExpr
+	}
+
+	var matchedKeys []itemKey
+	for _, key := range keys[start:] {
+		if matchesFilter(f.items[key], expr, names, values) {
+			matchedKeys = append(matchedKeys, key)
+		}
+	}
+
+	var lastEvaluatedKey map[string]types.AttributeValue
+	if limit != nil && int(*limit) < len(matchedKeys) {
+		matchedKeys = matchedKeys[:*limit]
+		last := matchedKeys[len(matchedKeys)-1]
+		lastEvaluatedKey = map[string]types.AttributeValue{
+			"vpc_id":     &types.AttributeValueMemberS{Value: last.pk},
+			"created_at": &types.AttributeValueMemberS{Value: last.sk},
+		}
+	}
+
+	items := make([]map[string]types.AttributeValue, 0, len(matchedKeys))
+	for _, key := range matchedKeys {
+		items = append(items, f.items[key])
+	}
+	return items, lastEvaluatedKey
+}
+
+// matchesFilter evaluates the small subset of FilterExpression syntax this
+// API's queries actually produce: clauses of the form "NOT begins_with(attr,
+// :val)", "begins_with(attr, :val)", or "attr = :val" (attr possibly a
+// "#name" placeholder), joined by " AND ".
+func matchesFilter(item map[string]types.AttributeValue, filterExpr string, names map[string]string, values map[string]types.AttributeValue) bool {
+	if filterExpr == "" {
+		return true
+	}
+	for _, clause := range strings.Split(filterExpr, " AND ") {
+		if !evalClause(item, strings.TrimSpace(clause), names, values) {
+			return false
+		}
+	}
+	return true
+}
+
+func evalClause(item map[string]types.AttributeValue, clause string, names map[string]string, values map[string]types.AttributeValue) bool {
+	negate := strings.HasPrefix(clause, "NOT ")
+	clause = strings.TrimPrefix(clause, "NOT ")
+
+	var match bool
+	if strings.HasPrefix(clause, "begins_with(") {
+		inner := strings.TrimSuffix(strings.TrimPrefix(clause, "begins_with("), ")")
+		parts := strings.SplitN(inner, ",", 2)
+		attr := resolveName(strings.TrimSpace(parts[0]), names)
+		prefix := attrString(values, strings.TrimSpace(parts[1]))
+		match = strings.HasPrefix(attrString(item, attr), prefix)
+	} else {
+		parts := strings.SplitN(clause, "=", 2)
+		attr := resolveName(strings.TrimSpace(parts[0]), names)
+		want := attrString(values, strings.TrimSpace(parts[1]))
+		match = attrString(item, attr) == want
+	}
+
+	if negate {
+		return !match
+	}
+	return match
+}
+
+func resolveName(raw string, names map[string]string) string {
+	if resolved, ok := names[raw]; ok {
+		return resolved
+	}
+	return raw
+}
+
+func sortByCreatedAt(items []map[string]types.AttributeValue, scanIndexForward *bool) {
+	sort.Slice(items, func(i, j int) bool {
+		return attrString(items[i], "created_at") < attrString(items[j], "created_at")
+	})
+	if scanIndexForward != nil && !*scanIndexForward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+}
+
+func reverse(keys []itemKey) {
+	for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+		keys[i], keys[j] = keys[j], keys[i]
+	}
+}
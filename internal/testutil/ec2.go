@@ -0,0 +1,107 @@
+// Package testutil provides in-memory fakes for the AWS SDK interfaces
+// defined by internal/awsvpc and internal/vpcstore, so handler-level tests
+// can exercise validation, idempotency, and rollback paths without hitting
+// AWS or requiring localstack.
+package testutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// FakeEC2 is a scriptable stand-in for awsvpc.EC2API. Calls to CreateVpc,
+// CreateSubnet, DeleteVpc and DeleteSubnet are counted, and behavior can be
+// overridden per-method (e.g. "CreateSubnet fails on the third call") by
+// setting the matching *Func field.
+type FakeEC2 struct {
+	CreateVpcFunc                 func(ctx context.Context, params *ec2.CreateVpcInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcOutput, error)
+	CreateSubnetFunc              func(ctx context.Context, params *ec2.CreateSubnetInput, optFns ...func(*ec2.Options)) (*ec2.CreateSubnetOutput, error)
+	DescribeAvailabilityZonesFunc func(ctx context.Context, params *ec2.DescribeAvailabilityZonesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error)
+	DeleteVpcFunc                 func(ctx context.Context, params *ec2.DeleteVpcInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVpcOutput, error)
+	DeleteSubnetFunc              func(ctx context.Context, params *ec2.DeleteSubnetInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSubnetOutput, error)
+	CreateTagsFunc                func(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error)
+	ModifyVpcAttributeFunc        func(ctx context.Context, params *ec2.ModifyVpcAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyVpcAttributeOutput, error)
+
+	CreateVpcCalls    int
+	CreateSubnetCalls int
+	DeleteVpcCalls    int
+	DeleteSubnetCalls int
+
+	nextVpcId    int
+	nextSubnetId int
+}
+
+func (f *FakeEC2) CreateVpc(ctx context.Context, params *ec2.CreateVpcInput, optFns ...func(*ec2.Options)) (*ec2.CreateVpcOutput, error) {
+	f.CreateVpcCalls++
+	if f.CreateVpcFunc != nil {
+		return f.CreateVpcFunc(ctx, params, optFns...)
+	}
+	f.nextVpcId++
+	return &ec2.CreateVpcOutput{
+		Vpc: &types.Vpc{
+			VpcId:     aws.String(fmt.Sprintf("vpc-fake%d", f.nextVpcId)),
+			CidrBlock: params.CidrBlock,
+		},
+	}, nil
+}
+
+func (f *FakeEC2) CreateSubnet(ctx context.Context, params *ec2.CreateSubnetInput, optFns ...func(*ec2.Options)) (*ec2.CreateSubnetOutput, error) {
+	f.CreateSubnetCalls++
+	if f.CreateSubnetFunc != nil {
+		return f.CreateSubnetFunc(ctx, params, optFns...)
+	}
+	f.nextSubnetId++
+	return &ec2.CreateSubnetOutput{
+		Subnet: &types.Subnet{
+			SubnetId:         aws.String(fmt.Sprintf("subnet-fake%d", f.nextSubnetId)),
+			CidrBlock:        params.CidrBlock,
+			AvailabilityZone: params.AvailabilityZone,
+		},
+	}, nil
+}
+
+func (f *FakeEC2) DescribeAvailabilityZones(ctx context.Context, params *ec2.DescribeAvailabilityZonesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error) {
+	if f.DescribeAvailabilityZonesFunc != nil {
+		return f.DescribeAvailabilityZonesFunc(ctx, params, optFns...)
+	}
+	return &ec2.DescribeAvailabilityZonesOutput{
+		AvailabilityZones: []types.AvailabilityZone{
+			{ZoneName: aws.String("us-east-1a")},
+			{ZoneName: aws.String("us-east-1b")},
+		},
+	}, nil
+}
+
+func (f *FakeEC2) DeleteVpc(ctx context.Context, params *ec2.DeleteVpcInput, optFns ...func(*ec2.Options)) (*ec2.DeleteVpcOutput, error) {
+	f.DeleteVpcCalls++
+	if f.DeleteVpcFunc != nil {
+		return f.DeleteVpcFunc(ctx, params, optFns...)
+	}
+	return &ec2.DeleteVpcOutput{}, nil
+}
+
+func (f *FakeEC2) DeleteSubnet(ctx context.Context, params *ec2.DeleteSubnetInput, optFns ...func(*ec2.Options)) (*ec2.DeleteSubnetOutput, error) {
+	f.DeleteSubnetCalls++
+	if f.DeleteSubnetFunc != nil {
+		return f.DeleteSubnetFunc(ctx, params, optFns...)
+	}
+	return &ec2.DeleteSubnetOutput{}, nil
+}
+
+func (f *FakeEC2) CreateTags(ctx context.Context, params *ec2.CreateTagsInput, optFns ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
+	if f.CreateTagsFunc != nil {
+		return f.CreateTagsFunc(ctx, params, optFns...)
+	}
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (f *FakeEC2) ModifyVpcAttribute(ctx context.Context, params *ec2.ModifyVpcAttributeInput, optFns ...func(*ec2.Options)) (*ec2.ModifyVpcAttributeOutput, error) {
+	if f.ModifyVpcAttributeFunc != nil {
+		return f.ModifyVpcAttributeFunc(ctx, params, optFns...)
+	}
+	return &ec2.ModifyVpcAttributeOutput{}, nil
+}
@@ -0,0 +1,395 @@
+package vpcstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Store wraps a DynamoAPI with the VPC table's access patterns.
+type Store struct {
+	Client    DynamoAPI
+	TableName string
+}
+
+// New builds a Store around a DynamoAPI implementation (typically
+// *dynamodb.Client in production, a fake from internal/testutil in tests)
+// for the given table.
+func New(client DynamoAPI, tableName string) *Store {
+	return &Store{Client: client, TableName: tableName}
+}
+
+// GetVPC returns the most recent record for vpcId, or nil if none exists.
+func (s *Store) GetVPC(ctx context.Context, vpcId string) (*VPCResource, error) {
+	result, err := s.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.TableName),
+		KeyConditionExpression: aws.String("vpc_id = :vpc_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":vpc_id": &types.AttributeValueMemberS{Value: vpcId},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vpc %s: %w", vpcId, err)
+	}
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+
+	var vpc VPCResource
+	if err := attributevalue.UnmarshalMap(result.Items[0], &vpc); err != nil {
+		return nil, fmt.Errorf("failed to parse vpc %s: %w", vpcId, err)
+	}
+	return &vpc, nil
+}
+
+// PutStatus writes (or overwrites) the VPC record at its current lifecycle
+// stage, so list/get endpoints can surface in-flight and failed VPCs rather
+// than only ones that finished successfully.
+func (s *Store) PutStatus(ctx context.Context, vpc VPCResource) error {
+	item, err := attributevalue.MarshalMap(vpc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vpc %s: %w", vpc.VPCId, err)
+	}
+
+	_, err = s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.TableName),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store vpc %s status %s: %w", vpc.VPCId, vpc.Status, err)
+	}
+	return nil
+}
+
+// DeleteRecord removes the VPC's DynamoDB record entirely. Used once the
+// underlying EC2 resources have actually been torn down.
+func (s *Store) DeleteRecord(ctx context.Context, vpcId, createdAt string) error {
+	_, err := s.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.TableName),
+		Key: map[string]types.AttributeValue{
+			"vpc_id":     &types.AttributeValueMemberS{Value: vpcId},
+			"created_at": &types.AttributeValueMemberS{Value: createdAt},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete vpc record %s: %w", vpcId, err)
+	}
+	return nil
+}
+
+// ListFilters holds the parsed, validated query-string parameters accepted
+// by List.
+type ListFilters struct {
+	Limit      int32
+	StartKey   map[string]types.AttributeValue
+	Status     string
+	CreatedBy  string
+	NamePrefix string
+}
+
+// ListResult is the page of VPCs returned by List, plus any records that
+// failed to unmarshal (surfaced as warnings instead of silently dropped).
+type ListResult struct {
+	VPCs             []VPCResource
+	Warnings         []string
+	LastEvaluatedKey map[string]types.AttributeValue
+}
+
+// List returns a page of VPCs matching filters, using the created_by GSI
+// when CreatedBy is set and falling back to a filtered Scan otherwise.
+func (s *Store) List(ctx context.Context, filters ListFilters) (ListResult, error) {
+	var items []map[string]types.AttributeValue
+	var lastEvaluatedKey map[string]types.AttributeValue
+	var err error
+
+	if filters.CreatedBy != "" {
+		items, lastEvaluatedKey, err = s.queryByCreatedBy(ctx, filters)
+	} else {
+		items, lastEvaluatedKey, err = s.scanWithFilters(ctx, filters)
+	}
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	result := ListResult{LastEvaluatedKey: lastEvaluatedKey}
+	for _, item := range items {
+		var vpc VPCResource
+		if err := attributevalue.UnmarshalMap(item, &vpc); err != nil {
+			vpcId := "unknown"
+			if vpcIdAttr, ok := item["vpc_id"].(*types.AttributeValueMemberS); ok {
+				vpcId = vpcIdAttr.Value
+			}
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to parse record %s: %v", vpcId, err))
+			continue
+		}
+		result.VPCs = append(result.VPCs, vpc)
+	}
+	return result, nil
+}
+
+func (s *Store) queryByCreatedBy(ctx context.Context, filters ListFilters) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	filterExpr, exprNames, exprValues := buildFilterExpression(filters, true)
+	exprValues[":created_by"] = &types.AttributeValueMemberS{Value: filters.CreatedBy}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:                 aws.String(s.TableName),
+		IndexName:                 aws.String(CreatedByIndexName),
+		KeyConditionExpression:    aws.String("created_by = :created_by"),
+		Limit:                     aws.Int32(filters.Limit),
+		ExclusiveStartKey:         filters.StartKey,
+		ScanIndexForward:          aws.Bool(false),
+		ExpressionAttributeValues: exprValues,
+	}
+	if filterExpr != "" {
+		queryInput.FilterExpression = aws.String(filterExpr)
+	}
+	if len(exprNames) > 0 {
+		queryInput.ExpressionAttributeNames = exprNames
+	}
+
+	result, err := s.Client.Query(ctx, queryInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Items, result.LastEvaluatedKey, nil
+}
+
+func (s *Store) scanWithFilters(ctx context.Context, filters ListFilters) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	filterExpr, exprNames, exprValues := buildFilterExpression(filters, false)
+
+	scanInput := &dynamodb.ScanInput{
+		TableName:         aws.String(s.TableName),
+		Limit:             aws.Int32(filters.Limit),
+		ExclusiveStartKey: filters.StartKey,
+	}
+	if filterExpr != "" {
+		scanInput.FilterExpression = aws.String(filterExpr)
+	}
+	if len(exprValues) > 0 {
+		scanInput.ExpressionAttributeValues = exprValues
+	}
+	if len(exprNames) > 0 {
+		scanInput.ExpressionAttributeNames = exprNames
+	}
+
+	result, err := s.Client.Scan(ctx, scanInput)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result.Items, result.LastEvaluatedKey, nil
+}
+
+// buildFilterExpression assembles a FilterExpression that always excludes
+// idempotency-tracking records, plus optional status and name_prefix
+// filters. queryingGSI is true when CreatedBy is already expressed as the
+// GSI's KeyConditionExpression, so it must not be repeated as a filter.
+func buildFilterExpression(filters ListFilters, queryingGSI bool) (string, map[string]string, map[string]types.AttributeValue) {
+	clauses := []string{"NOT begins_with(vpc_id, :idempotency_prefix)"}
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{
+		":idempotency_prefix": &types.AttributeValueMemberS{Value: IdempotencyKeyPrefix},
+	}
+
+	if filters.Status != "" {
+		clauses = append(clauses, "#status = :status")
+		names["#status"] = "status"
+		values[":status"] = &types.AttributeValueMemberS{Value: filters.Status}
+	}
+
+	if filters.NamePrefix != "" {
+		clauses = append(clauses, "begins_with(vpc_name, :name_prefix)")
+		values[":name_prefix"] = &types.AttributeValueMemberS{Value: filters.NamePrefix}
+	}
+
+	if !queryingGSI && filters.CreatedBy != "" {
+		clauses = append(clauses, "created_by = :created_by_filter")
+		values[":created_by_filter"] = &types.AttributeValueMemberS{Value: filters.CreatedBy}
+	}
+
+	return strings.Join(clauses, " AND "), names, values
+}
+
+// CheckOverlap returns the ID of the first VPC tracked in DynamoDB whose
+// CIDR the overlaps predicate reports as overlapping, or "" if none does;
+// failed, rolled-back and deleted records are skipped. It scans the table rather than calling EC2's DescribeVpcs so the
+// check also covers VPCs that exist in DynamoDB but have not finished
+// creating in EC2 yet.
+func (s *Store) CheckOverlap(ctx context.Context, overlaps func(existingCidr string) bool) (string, error) {
+	scanInput := &dynamodb.ScanInput{
+		TableName:            aws.String(s.TableName),
+		ProjectionExpression: aws.String("vpc_id, vpc_cidr, #s"),
+		ExpressionAttributeNames: map[string]string{
+			"#s": "status",
+		},
+	}
+
+	paginator := dynamodb.NewScanPaginator(s.Client, scanInput)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to scan existing VPCs: %w", err)
+		}
+
+		for _, item := range page.Items {
+			vpcIdAttr, _ := item["vpc_id"].(*types.AttributeValueMemberS)
+			if vpcIdAttr == nil || strings.HasPrefix(vpcIdAttr.Value, IdempotencyKeyPrefix) {
+				continue
+			}
+
+			statusAttr, _ := item["status"].(*types.AttributeValueMemberS)
+			if statusAttr != nil && (statusAttr.Value == StatusFailed || statusAttr.Value == StatusRolledBack || statusAttr.Value == StatusDeleted) {
+				continue
+			}
+
+			cidrAttr, _ := item["vpc_cidr"].(*types.AttributeValueMemberS)
+			if cidrAttr == nil {
+				continue
+			}
+
+			if overlaps(cidrAttr.Value) {
+				return vpcIdAttr.Value, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// IdempotencyResult is returned by ReserveIdempotencyKey.
+type IdempotencyResult struct {
+	// Cached is the prior response body when the key already completed.
+	Cached []byte
+	// InFlight is true when the key is pending (including a failed key that
+	// a concurrent retry has just re-claimed) and must not be retried from
+	// this request.
+	InFlight bool
+}
+
+// ReserveIdempotencyKey performs a conditional PutItem so only the first
+// caller with a given key proceeds to call EC2.
+func (s *Store) ReserveIdempotencyKey(ctx context.Context, key string) (IdempotencyResult, error) {
+	pk := IdempotencyKeyPrefix + key
+
+	_, err := s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.TableName),
+		Item: map[string]types.AttributeValue{
+			"vpc_id":     &types.AttributeValueMemberS{Value: pk},
+			"created_at": &types.AttributeValueMemberS{Value: idempotencySortKey},
+			"status":     &types.AttributeValueMemberS{Value: StatusPending},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(vpc_id)"),
+	})
+	if err == nil {
+		return IdempotencyResult{}, nil
+	}
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if !errors.As(err, &conditionFailed) {
+		return IdempotencyResult{}, err
+	}
+
+	getOutput, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.TableName),
+		Key: map[string]types.AttributeValue{
+			"vpc_id":     &types.AttributeValueMemberS{Value: pk},
+			"created_at": &types.AttributeValueMemberS{Value: idempotencySortKey},
+		},
+	})
+	if err != nil {
+		return IdempotencyResult{}, err
+	}
+	if getOutput.Item == nil {
+		// The record was deleted between the conditional put and our read;
+		// treat as in-flight so the caller retries rather than double-creating.
+		return IdempotencyResult{InFlight: true}, nil
+	}
+
+	statusAttr, _ := getOutput.Item["status"].(*types.AttributeValueMemberS)
+	if statusAttr != nil && statusAttr.Value == StatusFailed {
+		// A prior attempt with this key failed outright (e.g. a transient
+		// EC2 error). Re-claim the key instead of leaving it InFlight
+		// forever, which would permanently 409 any retry of the exact same
+		// auto-derived (sha256 of body) idempotency key. The reclaim is
+		// conditioned on the status we just observed so that two concurrent
+		// retries can't both win it and both call EC2.CreateVpc: only one
+		// PutItem succeeds, the other sees ConditionalCheckFailedException
+		// and falls back to InFlight.
+		_, err := s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.TableName),
+			Item: map[string]types.AttributeValue{
+				"vpc_id":     &types.AttributeValueMemberS{Value: pk},
+				"created_at": &types.AttributeValueMemberS{Value: idempotencySortKey},
+				"status":     &types.AttributeValueMemberS{Value: StatusPending},
+			},
+			ConditionExpression: aws.String("#status = :failed"),
+			ExpressionAttributeNames: map[string]string{
+				"#status": "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":failed": &types.AttributeValueMemberS{Value: StatusFailed},
+			},
+		})
+		if err != nil {
+			var conditionFailed *types.ConditionalCheckFailedException
+			if errors.As(err, &conditionFailed) {
+				return IdempotencyResult{InFlight: true}, nil
+			}
+			return IdempotencyResult{}, err
+		}
+		return IdempotencyResult{}, nil
+	}
+	if statusAttr == nil || statusAttr.Value != "completed" {
+		return IdempotencyResult{InFlight: true}, nil
+	}
+
+	responseAttr, _ := getOutput.Item["response"].(*types.AttributeValueMemberS)
+	if responseAttr == nil {
+		return IdempotencyResult{InFlight: true}, nil
+	}
+
+	return IdempotencyResult{Cached: []byte(responseAttr.Value)}, nil
+}
+
+// CompleteIdempotencyKey records the final response body for a key so
+// replays can be served without re-calling EC2.
+func (s *Store) CompleteIdempotencyKey(ctx context.Context, key string, response interface{}) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.TableName),
+		Item: map[string]types.AttributeValue{
+			"vpc_id":     &types.AttributeValueMemberS{Value: IdempotencyKeyPrefix + key},
+			"created_at": &types.AttributeValueMemberS{Value: idempotencySortKey},
+			"status":     &types.AttributeValueMemberS{Value: "completed"},
+			"response":   &types.AttributeValueMemberS{Value: string(body)},
+		},
+	})
+	return err
+}
+
+// MarkIdempotencyFailed records that the attempt for key failed, so the
+// next ReserveIdempotencyKey call for key re-claims it and retries instead
+// of serving a cached response.
+func (s *Store) MarkIdempotencyFailed(ctx context.Context, key string) error {
+	_, err := s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.TableName),
+		Item: map[string]types.AttributeValue{
+			"vpc_id":     &types.AttributeValueMemberS{Value: IdempotencyKeyPrefix + key},
+			"created_at": &types.AttributeValueMemberS{Value: idempotencySortKey},
+			"status":     &types.AttributeValueMemberS{Value: StatusFailed},
+		},
+	})
+	return err
+}
@@ -0,0 +1,52 @@
+package vpcstore
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/velhalkaran28/AWSDemo/internal/testutil"
+)
+
+func TestReserveIdempotencyKey_ConcurrentReclaimOnlyOneWinner(t *testing.T) {
+	fakeDynamo := testutil.NewFakeDynamo()
+	store := New(fakeDynamo, "vpc-table")
+	ctx := context.Background()
+
+	const key = "retry-key"
+	if err := store.MarkIdempotencyFailed(ctx, key); err != nil {
+		t.Fatalf("failed to seed a failed idempotency record: %v", err)
+	}
+
+	const racers = 10
+	results := make([]IdempotencyResult, racers)
+	errs := make([]error, racers)
+
+	var wg sync.WaitGroup
+	wg.Add(racers)
+	for i := 0; i < racers; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = store.ReserveIdempotencyKey(ctx, key)
+		}()
+	}
+	wg.Wait()
+
+	winners := 0
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error from racer %d: %v", i, err)
+		}
+		if !results[i].InFlight {
+			winners++
+		}
+	}
+
+	// Exactly one racer may reclaim the failed key and proceed to call EC2;
+	// the rest must back off as InFlight, or the same idempotency key would
+	// create more than one VPC.
+	if winners != 1 {
+		t.Fatalf("expected exactly 1 winner to reclaim the failed key, got %d", winners)
+	}
+}
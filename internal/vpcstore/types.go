@@ -0,0 +1,48 @@
+// Package vpcstore is the DynamoDB-backed persistence layer for VPC
+// metadata. It owns the VPCResource/SubnetResult record shapes and every
+// read/write access pattern against the VPC table, so the Lambda handlers
+// in cmd/vpc/* only deal with request/response shaping.
+package vpcstore
+
+// VPCResource is the record stored for each managed VPC.
+type VPCResource struct {
+	VPCId     string         `json:"vpc_id" dynamodbav:"vpc_id"`
+	CreatedAt string         `json:"created_at" dynamodbav:"created_at"`
+	CreatedBy string         `json:"created_by" dynamodbav:"created_by"`
+	UpdatedAt string         `json:"updated_at,omitempty" dynamodbav:"updated_at,omitempty"`
+	VPCCidr   string         `json:"vpc_cidr" dynamodbav:"vpc_cidr"`
+	VPCName   string         `json:"vpc_name" dynamodbav:"vpc_name"`
+	Status    string         `json:"status" dynamodbav:"status"`
+	Subnets   []SubnetResult `json:"subnets" dynamodbav:"subnets"`
+}
+
+// SubnetResult describes a single subnet created as part of a VPC.
+type SubnetResult struct {
+	SubnetId         string `json:"subnet_id" dynamodbav:"subnet_id"`
+	CIDRBlock        string `json:"cidr_block" dynamodbav:"cidr_block"`
+	AvailabilityZone string `json:"availability_zone" dynamodbav:"availability_zone"`
+	Name             string `json:"name" dynamodbav:"name"`
+}
+
+// VPC status lifecycle values, written to DynamoDB at each stage so
+// list/get endpoints can surface in-flight and failed VPCs.
+const (
+	StatusPending    = "pending"
+	StatusCreating   = "creating"
+	StatusCreated    = "created"
+	StatusUpdated    = "updated"
+	StatusDeleting   = "deleting"
+	StatusDeleted    = "deleted"
+	StatusFailed     = "failed"
+	StatusRolledBack = "rolled_back"
+)
+
+// IdempotencyKeyPrefix namespaces idempotency records inside the shared
+// vpc table so a second table/env var isn't needed.
+const IdempotencyKeyPrefix = "idempotency#"
+
+const idempotencySortKey = "record"
+
+// CreatedByIndexName is the GSI on created_by (hash) + created_at (range)
+// used to avoid a full table scan when listing VPCs filtered by owner.
+const CreatedByIndexName = "created_by-created_at-index"